@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strconv"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all runners and their results",
+	Long: "Export all runners with their results, as a JSON array in the API's\n" +
+		"format or as CSV with one row per result in the column layout that\n" +
+		"import reads.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportFormat != "json" && exportFormat != "csv" {
+			return fmt.Errorf("unknown format %q", exportFormat)
+		}
+		app := initApp()
+		defer app.close()
+		runners, responseErr := app.runnersService.GetRunnersBatch(
+			cmd.Context(), services.RunnersBatchQuery{})
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		for i, runner := range runners {
+			runners[i], responseErr = app.runnersService.GetRunner(
+				cmd.Context(), runner.ID)
+			if responseErr != nil {
+				return asError(responseErr)
+			}
+		}
+		output := cmd.OutOrStdout()
+		if exportOutput != "" && exportOutput != "-" {
+			file, err := os.Create(exportOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			output = file
+		}
+		if exportFormat == "csv" {
+			return exportCSV(output, runners)
+		}
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(runners)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "json",
+		"output format, json or csv")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "",
+		"file to write to instead of stdout")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func exportCSV(output io.Writer, runners []*models.Runner) error {
+	writer := csv.NewWriter(output)
+	header := append([]string{"first_name", "last_name", "country"},
+		resultColumns...)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, runner := range runners {
+		for _, result := range runner.Results {
+			err = writer.Write([]string{
+				runner.FirstName,
+				runner.LastName,
+				runner.Country,
+				runner.ID,
+				formatRaceTime(result.RaceResult),
+				result.Location,
+				strconv.Itoa(result.Position),
+				strconv.Itoa(result.Year),
+				result.RaceDate,
+				formatRaceTime(result.GunTime),
+				formatRaceTime(result.ChipTime),
+				strconv.Itoa(result.Precision),
+				result.Status,
+				result.StatusReason,
+				strconv.Itoa(result.Distance),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatRaceTime leaves the CSV cell empty for a missing time.
+func formatRaceTime(raceTime models.RaceTime) string {
+	if raceTime == 0 {
+		return ""
+	}
+	return raceTime.String()
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// resultColumns are the CSV columns import reads, named like the JSON fields
+// of models.Result. Only runner_id, race_result, location and year are
+// required; year may be left empty when race_date is given and race_result
+// when gun_time or chip_time is, or when the runner did not finish. An empty
+// distance is a marathon.
+var resultColumns = []string{"runner_id", "race_result", "location", "position",
+	"year", "race_date", "gun_time", "chip_time", "precision", "status",
+	"status_reason", "distance"}
+
+var optionalResultColumns = map[string]bool{"position": true, "race_date": true,
+	"gun_time": true, "chip_time": true, "precision": true, "status": true,
+	"status_reason": true, "distance": true}
+
+var importCmd = &cobra.Command{
+	Use:   "import <results.csv>",
+	Short: "Import race results from a CSV file",
+	Long: "Import race results from a CSV file whose header names the columns\n" +
+		strings.Join(resultColumns, ", ") + ". Every row is created through\n" +
+		"the results service, so personal and season bests are updated as if\n" +
+		"the result had been posted to the API. Invalid rows are reported and\n" +
+		"skipped.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		app := initApp()
+		defer app.close()
+		reader := csv.NewReader(file)
+		header, err := reader.Read()
+		if err != nil {
+			return fmt.Errorf("reading header: %w", err)
+		}
+		columns := map[string]int{}
+		for i, name := range header {
+			columns[strings.TrimSpace(name)] = i
+		}
+		for _, name := range resultColumns {
+			if _, ok := columns[name]; !ok && !optionalResultColumns[name] {
+				return fmt.Errorf("missing column %s", name)
+			}
+		}
+		imported, failed := 0, 0
+		for line := 2; ; line++ {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			result, err := parseResultRecord(record, columns)
+			if err == nil {
+				_, responseErr := app.resultsService.CreateResult(cmd.Context(),
+					operator, result)
+				err = asError(responseErr)
+			}
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "line %d: %v\n", line, err)
+				failed++
+				continue
+			}
+			imported++
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported %d results, %d failed\n",
+			imported, failed)
+		if failed > 0 {
+			return fmt.Errorf("%d results were not imported", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+func parseResultRecord(record []string, columns map[string]int) (*models.Result, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	result := &models.Result{
+		RunnerID:     field("runner_id"),
+		Location:     field("location"),
+		RaceDate:     field("race_date"),
+		Status:       strings.ToUpper(field("status")),
+		StatusReason: field("status_reason"),
+	}
+	var err error
+	for name, raceTime := range map[string]*models.RaceTime{
+		"race_result": &result.RaceResult,
+		"gun_time":    &result.GunTime,
+		"chip_time":   &result.ChipTime,
+	} {
+		if text := field(name); text != "" {
+			*raceTime, err = models.ParseRaceTime(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	if precision := field("precision"); precision != "" {
+		result.Precision, err = strconv.Atoi(precision)
+		if err != nil {
+			return nil, fmt.Errorf("invalid precision %q", precision)
+		}
+	}
+	if distance := field("distance"); distance != "" {
+		result.Distance, err = strconv.Atoi(distance)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distance %q", distance)
+		}
+	}
+	if position := field("position"); position != "" {
+		result.Position, err = strconv.Atoi(position)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %q", position)
+		}
+	}
+	if year := field("year"); year != "" || result.RaceDate == "" {
+		result.Year, err = strconv.Atoi(year)
+		if err != nil {
+			return nil, fmt.Errorf("invalid year %q", year)
+		}
+	}
+	return result, nil
+}
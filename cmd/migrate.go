@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+	"time"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage the database schema",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := initApp()
+		defer app.close()
+		applied, responseErr := app.migrationsService.Up(cmd.Context())
+		for _, migration := range applied {
+			fmt.Fprintf(cmd.OutOrStdout(), "Applied %04d_%s\n",
+				migration.Version, migration.Name)
+		}
+		if responseErr == nil && len(applied) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "Schema is up to date")
+		}
+		return asError(responseErr)
+	},
+}
+
+var migrateDownSteps int
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert the most recent migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := initApp()
+		defer app.close()
+		reverted, responseErr := app.migrationsService.Down(cmd.Context(),
+			migrateDownSteps)
+		for _, migration := range reverted {
+			fmt.Fprintf(cmd.OutOrStdout(), "Reverted %04d_%s\n",
+				migration.Version, migration.Name)
+		}
+		return asError(responseErr)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := initApp()
+		defer app.close()
+		statuses, responseErr := app.migrationsService.Status(cmd.Context())
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%04d\t%s\t%s\n",
+				status.Version, status.Name, appliedAt)
+		}
+		return writer.Flush()
+	},
+}
+
+func init() {
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1,
+		"number of migrations to revert")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+)
+
+var (
+	recomputeRunnerID   string
+	recomputeReportOnly bool
+)
+
+var recomputeBestsCmd = &cobra.Command{
+	Use:   "recompute-bests",
+	Short: "Recalculate stored personal and season bests from results",
+	Long: "Compare the personal_best and season_best stored on runners with the\n" +
+		"best results recorded for them and correct the rows that drifted.\n" +
+		"With --report-only the drifted rows are listed and nothing is changed.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := initApp()
+		defer app.close()
+		drifted, responseErr := app.runnersService.RecomputeBests(
+			cmd.Context(), recomputeRunnerID, recomputeReportOnly)
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "RUNNER\tNAME\tPERSONAL BEST\tSEASON BEST")
+		for _, drift := range drifted {
+			fmt.Fprintf(writer, "%s\t%s %s\t%s -> %s\t%s -> %s\n",
+				drift.RunnerID, drift.FirstName, drift.LastName,
+				orNone(drift.StoredPersonalBest), orNone(drift.PersonalBest),
+				orNone(drift.StoredSeasonBest), orNone(drift.SeasonBest))
+		}
+		writer.Flush()
+		if recomputeReportOnly {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d runners have drifted bests\n",
+				len(drifted))
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Corrected bests of %d runners\n",
+				len(drifted))
+		}
+		return nil
+	},
+}
+
+func init() {
+	recomputeBestsCmd.Flags().StringVar(&recomputeRunnerID, "runner", "",
+		"ID of the runner to recompute; all runners when empty")
+	recomputeBestsCmd.Flags().BoolVar(&recomputeReportOnly, "report-only", false,
+		"list drifted runners without changing them")
+	rootCmd.AddCommand(recomputeBestsCmd)
+}
+
+func orNone(value models.RaceTime) string {
+	if value == 0 {
+		return "none"
+	}
+	return value.String()
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/fentezi/runnerBook/config"
+	"github.com/fentezi/runnerBook/logging"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/server"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"log/slog"
+	"os"
+)
+
+var configName string
+
+// operator is the user the commands act as: whoever runs them has access to
+// the database and so administers the service, acting for every runner.
+var operator = &models.User{
+	Username:    "operator",
+	Role:        models.RoleAdmin,
+	Permissions: []string{models.PermissionRunnerAny},
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "runnerbook",
+	Short: "Runners and race results service",
+	Long: "runnerbook serves the runners API and provides the operational\n" +
+		"commands that manage its database. Without a subcommand it starts\n" +
+		"the HTTP server, like \"runnerbook serve\".",
+	SilenceUsage: true,
+	RunE:         runServe,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configName, "config", "runners",
+		"name of the configuration file, looked up in . and $HOME")
+}
+
+// Execute runs the command line and exits with a non-zero status on error.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// app wires the services the commands share, exactly as the HTTP server
+// does.
+type app struct {
+	config            *viper.Viper
+	dbHandler         *sql.DB
+	runnersService    *services.RunnersService
+	resultsService    *services.ResultsService
+	usersService      *services.UsersService
+	migrationsService *services.MigrationsService
+}
+
+func initConfig() *viper.Viper {
+	runnersConfig := config.InitConfig(configName)
+	_, err := logging.InitLogger(runnersConfig)
+	if err != nil {
+		slog.Error("Error while initializing logging", "error", err)
+		os.Exit(1)
+	}
+	return runnersConfig
+}
+
+func initApp() *app {
+	runnersConfig := initConfig()
+	dbHandler := server.InitDatabase(runnersConfig)
+	runnersRepository := repositories.NewRunnersRepository(dbHandler)
+	resultsRepository := repositories.NewResultsRepository(dbHandler)
+	usersRepository := repositories.NewUsersRepository(dbHandler)
+	schemaRepository := repositories.NewSchemaRepository(dbHandler)
+	ratingsRepository := repositories.NewRatingsRepository(dbHandler)
+	recordsRepository := repositories.NewRecordsRepository(dbHandler)
+	rolesRepository := repositories.NewRolesRepository(dbHandler)
+	countriesService := services.NewCountriesService(
+		repositories.NewCountriesRepository(dbHandler))
+	coachesService := services.NewCoachesService(
+		repositories.NewCoachesRepository(dbHandler), usersRepository,
+		runnersRepository)
+	seasonCalendar := server.InitSeasonCalendar(runnersConfig)
+	ageGrading := server.InitAgeGradingTable(runnersConfig)
+	return &app{
+		config:    runnersConfig,
+		dbHandler: dbHandler,
+		runnersService: services.NewRunnersService(runnersRepository,
+			resultsRepository, seasonCalendar, ageGrading, countriesService),
+		resultsService: services.NewResultsService(resultsRepository,
+			runnersRepository, recordsRepository, seasonCalendar, ageGrading,
+			services.NewRankingsService(ratingsRepository, countriesService),
+			coachesService),
+		usersService: services.NewUsersService(usersRepository,
+			rolesRepository),
+		migrationsService: services.NewMigrationsService(schemaRepository),
+	}
+}
+
+func (a *app) close() {
+	a.dbHandler.Close()
+}
+
+// asError converts a service error into an error for cobra to report.
+func asError(responseErr *models.ResponseError) error {
+	if responseErr == nil {
+		return nil
+	}
+	return errors.New(responseErr.Message)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+type seedRunner struct {
+	runner  models.Runner
+	results []models.Result
+}
+
+// seedRunners are sample runners for development databases. Result years are
+// relative to the current year so that season bests are populated.
+var seedRunners = []seedRunner{
+	{
+		runner: models.Runner{FirstName: "John", LastName: "Smith", Age: 30, Country: "United States"},
+		results: []models.Result{
+			{RaceResult: raceTime("02:13:13"), Location: "Boston", Position: 4, Year: -1},
+			{RaceResult: raceTime("02:09:41"), Location: "Chicago", Position: 2, Year: 0},
+		},
+	},
+	{
+		runner: models.Runner{FirstName: "Marjanna", LastName: "Komatich", Age: 24, Country: "Serbia"},
+		results: []models.Result{
+			{RaceResult: raceTime("02:27:05"), Location: "Belgrade", Position: 1, Year: -2},
+			{RaceResult: raceTime("02:24:37"), Location: "Vienna", Position: 3, Year: 0},
+		},
+	},
+	{
+		runner: models.Runner{FirstName: "Kenji", LastName: "Watanabe", Age: 41, Country: "Japan"},
+		results: []models.Result{
+			{RaceResult: raceTime("02:18:50"), Location: "Tokyo", Position: 12, Year: -1},
+		},
+	},
+	{
+		runner: models.Runner{FirstName: "Amina", LastName: "Okafor", Age: 28, Country: "Nigeria"},
+		results: []models.Result{
+			{RaceResult: raceTime("02:31:12"), Location: "Lagos", Position: 2, Year: -3},
+			{RaceResult: raceTime("02:26:48"), Location: "London", Position: 9, Year: -1},
+		},
+	},
+}
+
+func raceTime(text string) models.RaceTime {
+	parsed, err := models.ParseRaceTime(text)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+var seedForce bool
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Fill an empty database with sample runners and results",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := initApp()
+		defer app.close()
+		existing, responseErr := app.runnersService.GetRunnersBatch(
+			cmd.Context(), services.RunnersBatchQuery{})
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		if len(existing) > 0 && !seedForce {
+			return errors.New("database already has runners, use --force to seed anyway")
+		}
+		currentYear := time.Now().Year()
+		results := 0
+		for _, seed := range seedRunners {
+			runner := seed.runner
+			created, responseErr := app.runnersService.CreateRunner(
+				cmd.Context(), &runner)
+			if responseErr != nil {
+				return asError(responseErr)
+			}
+			for _, result := range seed.results {
+				result.RunnerID = created.ID
+				result.Year += currentYear
+				_, responseErr = app.resultsService.CreateResult(
+					cmd.Context(), operator, &result)
+				if responseErr != nil {
+					return asError(responseErr)
+				}
+				results++
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Seeded %d runners and %d results\n",
+			len(seedRunners), results)
+		return nil
+	},
+}
+
+func init() {
+	seedCmd.Flags().BoolVar(&seedForce, "force", false,
+		"seed even if the database already has runners")
+	rootCmd.AddCommand(seedCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/fentezi/runnerBook/config"
+	"github.com/fentezi/runnerBook/server"
+	"github.com/fentezi/runnerBook/tracing"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start the HTTP server",
+	Args:  cobra.NoArgs,
+	RunE:  runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	slog.Info("Starting Runners App")
+	slog.Info("Initializing configuration")
+	runnersConfig := initConfig()
+	config.WatchConfig(runnersConfig)
+	slog.Info("Initializing tracing")
+	shutdownTracing, err := tracing.InitTracing(runnersConfig)
+	if err != nil {
+		return fmt.Errorf("initializing tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+	slog.Info("Initializing database")
+	dbHandler := server.InitDatabase(runnersConfig)
+	slog.Info("Initializing HTTP server")
+	httpServer := server.InitHttpServer(runnersConfig, dbHandler)
+	httpServer.Start(ctx)
+	return nil
+}
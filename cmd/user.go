@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+)
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage API users",
+}
+
+var userCreateRole string
+
+var userCreateCmd = &cobra.Command{
+	Use:   "create <username>",
+	Short: "Create a user; the password is read from the terminal or stdin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword(cmd)
+		if err != nil {
+			return err
+		}
+		app := initApp()
+		defer app.close()
+		user, responseErr := app.usersService.CreateUser(cmd.Context(),
+			&models.User{
+				Username: args[0],
+				Password: password,
+				Role:     userCreateRole,
+			})
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Created user %s (%s) with role %s\n",
+			user.Username, user.ID, user.Role)
+		return nil
+	},
+}
+
+var userPasswdCmd = &cobra.Command{
+	Use:   "passwd <username>",
+	Short: "Change a user's password and sign the user out",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword(cmd)
+		if err != nil {
+			return err
+		}
+		app := initApp()
+		defer app.close()
+		responseErr := app.usersService.SetPassword(cmd.Context(),
+			args[0], password)
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Changed password of %s\n", args[0])
+		return nil
+	},
+}
+
+var userRoleCmd = &cobra.Command{
+	Use:   "role <username> <role>",
+	Short: "Change a user's role and sign the user out",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app := initApp()
+		defer app.close()
+		responseErr := app.usersService.SetRole(cmd.Context(), args[0], args[1])
+		if responseErr != nil {
+			return asError(responseErr)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Changed role of %s to %s\n",
+			args[0], args[1])
+		return nil
+	},
+}
+
+func init() {
+	userCreateCmd.Flags().StringVar(&userCreateRole, "role", models.RoleRunner,
+		"role of the new user, one of the roles stored in the database")
+	userCmd.AddCommand(userCreateCmd, userPasswdCmd, userRoleCmd)
+	rootCmd.AddCommand(userCmd)
+}
+
+// readPassword prompts twice without echo when stdin is a terminal and
+// otherwise reads the first line of stdin, so that passwords never appear in
+// the process arguments or shell history.
+func readPassword(cmd *cobra.Command) (string, error) {
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+	password, err := term.ReadPassword(stdin)
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), "Repeat password: ")
+	repeated, err := term.ReadPassword(stdin)
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err != nil {
+		return "", err
+	}
+	if string(password) != string(repeated) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(password), nil
+}
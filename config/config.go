@@ -1,18 +1,291 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"github.com/fentezi/runnerBook/logging"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
-	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
+// EnvPrefix is the prefix of the environment variables that override
+// settings: database.max_open_connections is read from
+// RUNNERS_DATABASE_MAX_OPEN_CONNECTIONS. Appending _FILE to a variable name
+// reads the value from the named file instead, which is how secrets mounted
+// by the orchestrator are passed in.
+const EnvPrefix = "RUNNERS"
+
+const fileSuffix = "_FILE"
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+	kindFloat
+	kindDuration
+	kindStringList
+)
+
+type setting struct {
+	kind         kind
+	defaultValue any
+	required     bool
+}
+
+// settings lists every key the application reads. A key in runners.toml that
+// is not listed here is reported by Validate rather than silently ignored.
+var settings = map[string]setting{
+	"database.connecting_string":       {kind: kindString, required: true},
+	"database.password":                {kind: kindString},
+	"database.max_idle_connections":    {kind: kindInt, defaultValue: 2},
+	"database.max_open_connections":    {kind: kindInt, defaultValue: 0},
+	"database.connection_max_lifetime": {kind: kindDuration, defaultValue: "0s"},
+	"database.driver_name":             {kind: kindString, defaultValue: "postgres"},
+	"http.server_address":              {kind: kindString, defaultValue: ":8080"},
+	"http.readiness_timeout":           {kind: kindDuration, defaultValue: "2s"},
+	"logging.format":                   {kind: kindString, defaultValue: "json"},
+	"logging.level":                    {kind: kindString, defaultValue: "info"},
+	"tracing.exporter":                 {kind: kindString, defaultValue: "none"},
+	"tracing.otlp_endpoint":            {kind: kindString},
+	"tracing.otlp_insecure":            {kind: kindBool, defaultValue: false},
+	"tracing.sample_ratio":             {kind: kindFloat, defaultValue: 1.0},
+	"rate_limit.requests_per_second":   {kind: kindFloat, defaultValue: 0},
+	"rate_limit.burst":                 {kind: kindInt, defaultValue: 0},
+	"cors.allowed_origins":             {kind: kindStringList},
+	"season.timezone":                  {kind: kindString, defaultValue: "UTC"},
+	"season.start":                     {kind: kindString, defaultValue: "01-01"},
+	"season.rollover":                  {kind: kindBool, defaultValue: true},
+	"age_grading.factors_file":         {kind: kindString},
+	"performance.window_days":          {kind: kindInt, defaultValue: 365},
+}
+
+// settingPrefixes lists families of keys with free-form names, such as the
+// feature flags under features.
+var settingPrefixes = map[string]setting{
+	"features.": {kind: kindBool},
+}
+
+func lookupSetting(key string) (setting, bool) {
+	if setting, ok := settings[key]; ok {
+		return setting, true
+	}
+	for prefix, setting := range settingPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return setting, true
+		}
+	}
+	return setting{}, false
+}
+
 func InitConfig(fileName string) *viper.Viper {
 	config := viper.New()
 	config.SetConfigName(fileName)
 	config.AddConfigPath(".")
 	config.AddConfigPath("$HOME")
 	if err := config.ReadInConfig(); err != nil {
-		log.Fatal("Error while parsing configuration file", err)
+		slog.Error("Error while parsing configuration file", "error", err)
+		os.Exit(1)
+	}
+	if err := loadEnvironment(config); err != nil {
+		slog.Error("Error while reading configuration from environment", "error", err)
+		os.Exit(1)
+	}
+	if err := Validate(config); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
 	}
+	runtime, err := newRuntime(config)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	applyRuntime(runtime)
 	return config
+}
 
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// loadEnvironment sets defaults and binds every known setting to its
+// environment variable. A *_FILE variable takes precedence over the plain one.
+func loadEnvironment(config *viper.Viper) error {
+	config.SetEnvPrefix(EnvPrefix)
+	config.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for key, setting := range settings {
+		if setting.defaultValue != nil {
+			config.SetDefault(key, setting.defaultValue)
+		}
+		err := config.BindEnv(key)
+		if err != nil {
+			return err
+		}
+		fileName, ok := os.LookupEnv(EnvName(key) + fileSuffix)
+		if !ok {
+			continue
+		}
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", EnvName(key), fileSuffix, err)
+		}
+		config.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+	return nil
+}
+
+// Validate checks that every key is known, required keys are present and
+// every value parses as the type the application reads it as. All problems
+// are reported together.
+func Validate(config *viper.Viper) error {
+	var errs []error
+	for _, key := range config.AllKeys() {
+		if setting, ok := lookupSetting(key); ok {
+			if _, known := settings[key]; !known {
+				if err := checkKind(config, key, setting.kind); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		if suggestion := closestKey(key); suggestion != "" {
+			errs = append(errs, fmt.Errorf(
+				"unknown setting %s (did you mean %s?)", key, suggestion))
+		} else {
+			errs = append(errs, fmt.Errorf("unknown setting %s", key))
+		}
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		setting := settings[key]
+		if !config.IsSet(key) {
+			if setting.required {
+				errs = append(errs, fmt.Errorf(
+					"missing required setting %s (or %s)", key, EnvName(key)))
+			}
+			continue
+		}
+		if err := checkKind(config, key, setting.kind); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if config.GetInt("database.max_idle_connections") < 0 {
+		errs = append(errs, errors.New(
+			"database.max_idle_connections must not be negative"))
+	}
+	if config.GetInt("database.max_open_connections") < 0 {
+		errs = append(errs, errors.New(
+			"database.max_open_connections must not be negative"))
+	}
+	maxOpen := config.GetInt("database.max_open_connections")
+	if maxOpen > 0 && config.GetInt("database.max_idle_connections") > maxOpen {
+		errs = append(errs, errors.New(
+			"database.max_idle_connections must not exceed database.max_open_connections"))
+	}
+	if _, err := logging.ParseLevel(config.GetString("logging.level")); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %w", err))
+	}
+	switch config.GetString("logging.format") {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("logging.format: unknown format %q",
+			config.GetString("logging.format")))
+	}
+	switch config.GetString("tracing.exporter") {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: unknown exporter %q",
+			config.GetString("tracing.exporter")))
+	}
+	if config.GetFloat64("rate_limit.requests_per_second") < 0 {
+		errs = append(errs, errors.New(
+			"rate_limit.requests_per_second must not be negative"))
+	}
+	if config.GetInt("rate_limit.burst") < 0 {
+		errs = append(errs, errors.New("rate_limit.burst must not be negative"))
+	}
+	ratio := config.GetFloat64("tracing.sample_ratio")
+	if ratio < 0 || ratio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+	if _, err := time.LoadLocation(config.GetString("season.timezone")); err != nil {
+		errs = append(errs, fmt.Errorf("season.timezone: %w", err))
+	}
+	if _, err := time.Parse("01-02", config.GetString("season.start")); err != nil {
+		errs = append(errs, fmt.Errorf("season.start: expected MM-DD, got %q",
+			config.GetString("season.start")))
+	}
+	if config.GetInt("performance.window_days") <= 0 {
+		errs = append(errs, errors.New("performance.window_days must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func checkKind(config *viper.Viper, key string, kind kind) error {
+	value := config.Get(key)
+	var err error
+	switch kind {
+	case kindInt:
+		_, err = cast.ToIntE(value)
+	case kindBool:
+		_, err = cast.ToBoolE(value)
+	case kindFloat:
+		_, err = cast.ToFloat64E(value)
+	case kindDuration:
+		_, err = cast.ToDurationE(value)
+	case kindStringList:
+		_, err = cast.ToStringSliceE(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// closestKey suggests the known key that is at most two edits away from key,
+// e.g. database.max_idle_connections for database.max_idle_connection.
+func closestKey(key string) string {
+	best, bestDistance := "", 3
+	for known := range settings {
+		distance := editDistance(key, known)
+		if distance < bestDistance ||
+			(distance == bestDistance && known < best) {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
 }
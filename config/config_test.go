@@ -0,0 +1,110 @@
+package config
+
+import (
+	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestConfig(t *testing.T, toml string) *viper.Viper {
+	config := viper.New()
+	config.SetConfigType("toml")
+	err := config.ReadConfig(strings.NewReader(toml))
+	assert.Equal(t, nil, err)
+	err = loadEnvironment(config)
+	assert.Equal(t, nil, err)
+	return config
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{
+			name: "Valid",
+			toml: "[database]\nconnecting_string = \"host=localhost\"\n" +
+				"max_idle_connections = 5\nmax_open_connections = 20\n",
+			want: "",
+		},
+		{
+			name: "Misspelled_Key",
+			toml: "[database]\nconnecting_string = \"host=localhost\"\n" +
+				"max_idle_connection = 5\n",
+			want: "unknown setting database.max_idle_connection " +
+				"(did you mean database.max_idle_connections?)",
+		},
+		{
+			name: "Missing_Connection_String",
+			toml: "[http]\nserver_address = \":8080\"\n",
+			want: "missing required setting database.connecting_string " +
+				"(or RUNNERS_DATABASE_CONNECTING_STRING)",
+		},
+		{
+			name: "Invalid_Duration",
+			toml: "[database]\nconnecting_string = \"host=localhost\"\n" +
+				"connection_max_lifetime = \"soon\"\n",
+			want: "database.connection_max_lifetime: time: invalid duration \"soon\"",
+		},
+		{
+			name: "Idle_Exceeds_Open",
+			toml: "[database]\nconnecting_string = \"host=localhost\"\n" +
+				"max_idle_connections = 30\nmax_open_connections = 20\n",
+			want: "database.max_idle_connections must not exceed " +
+				"database.max_open_connections",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(newTestConfig(t, test.toml))
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	err := os.WriteFile(secretFile, []byte("s3cret\n"), 0600)
+	assert.Equal(t, nil, err)
+	t.Setenv("RUNNERS_HTTP_SERVER_ADDRESS", ":9090")
+	t.Setenv("RUNNERS_DATABASE_PASSWORD_FILE", secretFile)
+	config := newTestConfig(t,
+		"[database]\nconnecting_string = \"host=localhost\"\n"+
+			"[http]\nserver_address = \":8080\"\n")
+	assert.Equal(t, nil, Validate(config))
+	assert.Equal(t, ":9090", config.GetString("http.server_address"))
+	assert.Equal(t, "s3cret", config.GetString("database.password"))
+}
+
+func TestReloadConfig(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "runners.toml")
+	write := func(toml string) {
+		err := os.WriteFile(fileName, []byte(toml), 0600)
+		assert.Equal(t, nil, err)
+	}
+	write("[database]\nconnecting_string = \"host=localhost\"\n")
+	config := viper.New()
+	config.SetConfigFile(fileName)
+	assert.Equal(t, nil, config.ReadInConfig())
+	write("[database]\nconnecting_string = \"host=localhost\"\n" +
+		"[logging]\nlevel = \"debug\"\n" +
+		"[rate_limit]\nrequests_per_second = 5\n" +
+		"[features]\nage_grading = true\n")
+	reloadConfig(config, fileName)
+	assert.Equal(t, "DEBUG", Current().LogLevel.String())
+	assert.Equal(t, 5.0, Current().RequestsPerSecond)
+	assert.Equal(t, true, Current().Feature("age_grading"))
+	write("[database]\nconnecting_string = \"host=localhost\"\n" +
+		"[logging]\nlevel = \"loud\"\n")
+	reloadConfig(config, fileName)
+	assert.Equal(t, "DEBUG", Current().LogLevel.String())
+	assert.Equal(t, 5.0, Current().RequestsPerSecond)
+}
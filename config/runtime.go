@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"github.com/fentezi/runnerBook/logging"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Runtime holds the settings that take effect without a restart. Everything
+// else, e.g. the database connection or the listen address, is read once at
+// startup.
+type Runtime struct {
+	LogLevel           slog.Level
+	RequestsPerSecond  float64
+	Burst              int
+	CORSAllowedOrigins []string
+	Features           map[string]bool
+}
+
+// Feature reports whether the feature flag features.<name> is on.
+func (r *Runtime) Feature(name string) bool {
+	return r.Features[name]
+}
+
+// structuralPrefixes are the settings that only take effect after a restart.
+var structuralPrefixes = []string{"database.", "http.", "tracing.", "logging.format", "season.", "age_grading.", "performance."}
+
+var current atomic.Pointer[Runtime]
+
+func init() {
+	current.Store(&Runtime{LogLevel: slog.LevelInfo, Features: map[string]bool{}})
+}
+
+// Current returns the runtime settings in effect.
+func Current() *Runtime {
+	return current.Load()
+}
+
+func newRuntime(config *viper.Viper) (*Runtime, error) {
+	level, err := logging.ParseLevel(config.GetString("logging.level"))
+	if err != nil {
+		return nil, err
+	}
+	features := map[string]bool{}
+	for name := range config.GetStringMap("features") {
+		features[name] = config.GetBool("features." + name)
+	}
+	return &Runtime{
+		LogLevel:           level,
+		RequestsPerSecond:  config.GetFloat64("rate_limit.requests_per_second"),
+		Burst:              config.GetInt("rate_limit.burst"),
+		CORSAllowedOrigins: config.GetStringSlice("cors.allowed_origins"),
+		Features:           features,
+	}, nil
+}
+
+func applyRuntime(runtime *Runtime) {
+	current.Store(runtime)
+	logging.Level.Set(runtime.LogLevel)
+}
+
+// WatchConfig reloads the runtime settings whenever the configuration file
+// changes. The file is re-read into a fresh viper instance and validated as a
+// whole; an invalid file is rejected and the previous settings stay in
+// effect. Changes to settings that need a restart are reported but ignored.
+func WatchConfig(config *viper.Viper) {
+	fileName := config.ConfigFileUsed()
+	watcher := viper.New()
+	watcher.SetConfigFile(fileName)
+	watcher.OnConfigChange(func(event fsnotify.Event) {
+		reloadConfig(config, fileName)
+	})
+	watcher.WatchConfig()
+}
+
+func reloadConfig(config *viper.Viper, fileName string) {
+	next := viper.New()
+	next.SetConfigFile(fileName)
+	err := next.ReadInConfig()
+	if err == nil {
+		err = loadEnvironment(next)
+	}
+	if err == nil {
+		err = Validate(next)
+	}
+	var runtime *Runtime
+	if err == nil {
+		runtime, err = newRuntime(next)
+	}
+	if err != nil {
+		slog.Error("Rejected configuration change, keeping previous settings",
+			"file", fileName, "error", err)
+		return
+	}
+	if changed := changedStructuralSettings(config, next); len(changed) > 0 {
+		slog.Warn("Configuration change requires a restart to take effect",
+			"settings", changed)
+	}
+	previous := Current()
+	applyRuntime(runtime)
+	slog.Info("Configuration reloaded",
+		"file", fileName,
+		"log_level", runtime.LogLevel.String(),
+		"previous_log_level", previous.LogLevel.String(),
+		"requests_per_second", runtime.RequestsPerSecond,
+		"burst", runtime.Burst,
+		"cors_allowed_origins", runtime.CORSAllowedOrigins,
+		"features", runtime.Features)
+}
+
+func changedStructuralSettings(config, next *viper.Viper) []string {
+	var changed []string
+	for _, key := range next.AllKeys() {
+		for _, prefix := range structuralPrefixes {
+			if strings.HasPrefix(key, prefix) &&
+				fmt.Sprint(config.Get(key)) != fmt.Sprint(next.Get(key)) {
+				changed = append(changed, key)
+			}
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// userKey is the gin context key the authorized user is stored under.
+const userKey = "user"
+
+// Authorizer checks, before a handler runs, that the user signed in with the
+// Token header has the permission the route requires.
+type Authorizer struct {
+	usersService *services.UsersService
+}
+
+func NewAuthorizer(usersService *services.UsersService) *Authorizer {
+	return &Authorizer{usersService: usersService}
+}
+
+// Require returns a middleware that aborts the request unless the user's role
+// grants permission. Handlers get the user with currentUser.
+func (a Authorizer) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken := c.Request.Header.Get("Token")
+		user, responseErr := a.usersService.AuthorizedUser(c.Request.Context(),
+			accessToken, permission)
+		if responseErr != nil {
+			abortWithError(c, responseErr)
+			return
+		}
+		if user == nil {
+			abortWithError(c, &models.ResponseError{
+				Message: "Insufficient permissions",
+				Status:  http.StatusUnauthorized,
+			})
+			return
+		}
+		c.Set(userKey, user)
+		c.Next()
+	}
+}
+
+// currentUser returns the user authorized by Require.
+func currentUser(c *gin.Context) *models.User {
+	return c.MustGet(userKey).(*models.User)
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"encoding/json"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type ClubsController struct {
+	clubsService *services.ClubsService
+}
+
+func NewClubsController(clubsService *services.ClubsService) *ClubsController {
+	return &ClubsController{
+		clubsService: clubsService,
+	}
+}
+
+func (ch ClubsController) CreateClub(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading create club request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	var club models.Club
+	err = json.Unmarshal(body, &club)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling "+
+				"create club request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	response, responseErr := ch.clubsService.CreateClub(c.Request.Context(), &club)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (ch ClubsController) UpdateClub(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading update club request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	var club models.Club
+	err = json.Unmarshal(body, &club)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling "+
+				"update club request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	responseErr := ch.clubsService.UpdateClub(c.Request.Context(), &club)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ch ClubsController) DeleteClub(c *gin.Context) {
+	responseErr := ch.clubsService.DeleteClub(c.Request.Context(), c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ch ClubsController) GetClub(c *gin.Context) {
+	response, responseErr := ch.clubsService.GetClub(c.Request.Context(), c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (ch ClubsController) GetClubs(c *gin.Context) {
+	response, responseErr := ch.clubsService.GetClubs(c.Request.Context())
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (ch ClubsController) GetClubBests(c *gin.Context) {
+	response, responseErr := ch.clubsService.GetClubBests(c.Request.Context(),
+		c.Param("id"), c.Query("distance"), c.Query("year"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (ch ClubsController) CreateMembership(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading create membership request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	var membership models.Membership
+	err = json.Unmarshal(body, &membership)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling "+
+				"create membership request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	response, responseErr := ch.clubsService.CreateMembership(c.Request.Context(),
+		&membership)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (ch ClubsController) UpdateMembership(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading update membership request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	var membership models.Membership
+	err = json.Unmarshal(body, &membership)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling "+
+				"update membership request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	responseErr := ch.clubsService.UpdateMembership(c.Request.Context(),
+		&membership)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ch ClubsController) DeleteMembership(c *gin.Context) {
+	responseErr := ch.clubsService.DeleteMembership(c.Request.Context(),
+		c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ch ClubsController) GetRunnerMemberships(c *gin.Context) {
+	response, responseErr := ch.clubsService.GetRunnerMemberships(c.Request.Context(),
+		c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (ch ClubsController) GetTeamResults(c *gin.Context) {
+	params := c.Request.URL.Query()
+	response, responseErr := ch.clubsService.GetTeamResults(c.Request.Context(),
+		services.TeamResultsQuery{
+			Race:     params.Get("race"),
+			Year:     params.Get("year"),
+			Distance: params.Get("distance"),
+			Size:     params.Get("size"),
+			Scoring:  params.Get("scoring"),
+		})
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
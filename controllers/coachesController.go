@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type CoachesController struct {
+	coachesService *services.CoachesService
+}
+
+func NewCoachesController(coachesService *services.CoachesService) *CoachesController {
+	return &CoachesController{
+		coachesService: coachesService,
+	}
+}
+
+func (ch CoachesController) AssignAthlete(c *gin.Context) {
+	responseErr := ch.coachesService.AssignAthlete(c.Request.Context(),
+		c.Param("username"), c.Param("runner_id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ch CoachesController) UnassignAthlete(c *gin.Context) {
+	responseErr := ch.coachesService.UnassignAthlete(c.Request.Context(),
+		c.Param("username"), c.Param("runner_id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ch CoachesController) GetAthletes(c *gin.Context) {
+	user := currentUser(c)
+	athletes, responseErr := ch.coachesService.GetAthletes(c.Request.Context(),
+		user, c.Param("username"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, athletes)
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type CountriesController struct {
+	countriesService *services.CountriesService
+}
+
+func NewCountriesController(countriesService *services.CountriesService) *CountriesController {
+	return &CountriesController{
+		countriesService: countriesService,
+	}
+}
+
+func (ch CountriesController) GetCountries(c *gin.Context) {
+	countries, responseErr := ch.countriesService.GetCountries(c.Request.Context())
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, countries)
+}
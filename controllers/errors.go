@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/logging"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/gin-gonic/gin"
+)
+
+// abortWithError stops the handler chain and writes responseErr, tagged with
+// the request ID so that clients can quote it when reporting a problem.
+func abortWithError(c *gin.Context, responseErr *models.ResponseError) {
+	responseErr.RequestID = logging.RequestID(c.Request.Context())
+	c.AbortWithStatusJSON(responseErr.Status, responseErr)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type PerformanceController struct {
+	performanceService *services.PerformanceService
+}
+
+func NewPerformanceController(performanceService *services.PerformanceService) *PerformanceController {
+	return &PerformanceController{
+		performanceService: performanceService,
+	}
+}
+
+func (ph PerformanceController) GetPredictions(c *gin.Context) {
+	predictions, responseErr := ph.performanceService.GetPredictions(
+		c.Request.Context(), c.Param("id"), c.Query("model"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, predictions)
+}
+
+func (ph PerformanceController) GetPaces(c *gin.Context) {
+	paces, responseErr := ph.performanceService.GetPaces(
+		c.Request.Context(), c.Param("id"), c.Query("unit"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, paces)
+}
+
+func (ph PerformanceController) GetStats(c *gin.Context) {
+	stats, responseErr := ph.performanceService.GetStats(
+		c.Request.Context(), c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type RankingsController struct {
+	rankingsService *services.RankingsService
+}
+
+func NewRankingsController(rankingsService *services.RankingsService) *RankingsController {
+	return &RankingsController{
+		rankingsService: rankingsService,
+	}
+}
+
+func (rh RankingsController) GetRankings(c *gin.Context) {
+	params := c.Request.URL.Query()
+	rankings, responseErr := rh.rankingsService.GetRankings(c.Request.Context(),
+		services.RankingsQuery{
+			Distance: params.Get("distance"),
+			Date:     params.Get("date"),
+			Country:  params.Get("country"),
+			Category: params.Get("category"),
+		})
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, rankings)
+}
+
+func (rh RankingsController) RecomputeRatings(c *gin.Context) {
+	responseErr := rh.rankingsService.RecomputeRatings(c.Request.Context())
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type RecordsController struct {
+	recordsService *services.RecordsService
+}
+
+func NewRecordsController(recordsService *services.RecordsService) *RecordsController {
+	return &RecordsController{
+		recordsService: recordsService,
+	}
+}
+
+func (rh RecordsController) GetRecords(c *gin.Context) {
+	params := c.Request.URL.Query()
+	records, responseErr := rh.recordsService.GetRecords(c.Request.Context(),
+		services.RecordsQuery{
+			Scope:    params.Get("scope"),
+			Country:  params.Get("country"),
+			Category: params.Get("category"),
+			Distance: params.Get("distance"),
+		})
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
@@ -2,79 +2,116 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/services"
 	"github.com/gin-gonic/gin"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
 type ResultsController struct {
 	resultsService *services.ResultsService
-	usersService   *services.UsersService
 }
 
-func NewResultsController(resultsService *services.ResultsService, usersService *services.UsersService) *ResultsController {
+func NewResultsController(resultsService *services.ResultsService) *ResultsController {
 	return &ResultsController{
 		resultsService: resultsService,
-		usersService:   usersService,
 	}
 }
 
 func (rh ResultsController) CreateResult(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN})
-	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
-		return
-	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
-		return
-	}
+	user := currentUser(c)
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println(
-			"Error while reading create result request body", err)
-		c.AbortWithError(http.StatusInternalServerError, err)
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading create result request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
 	var result models.Result
 	err = json.Unmarshal(body, &result)
+	if errors.Is(err, models.ErrInvalidRaceTime) {
+		abortWithError(c, &models.ResponseError{
+			Message: "Invalid race time",
+			Status:  http.StatusBadRequest,
+		})
+		return
+	}
 	if err != nil {
-		log.Println(
+		slog.ErrorContext(c.Request.Context(),
 			"Error while unmarshaling "+
-				"creates result request body", err)
-		c.AbortWithError(http.StatusInternalServerError, err)
+				"creates result request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
-	response, responseErr := rh.resultsService.CreateResult(&result)
+	response, responseErr := rh.resultsService.CreateResult(c.Request.Context(),
+		user, &result)
 	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.JSON(http.StatusOK, response)
 }
 
 func (rh *ResultsController) DeleteResult(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN})
+	resultID := c.Param("id")
+	responseErr := rh.resultsService.DeleteResult(c.Request.Context(), resultID)
 	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (rh ResultsController) AddResultNote(c *gin.Context) {
+	user := currentUser(c)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading add result note request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
+	var note models.ResultNote
+	err = json.Unmarshal(body, &note)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling "+
+				"add result note request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
-	resultID := c.Param("id")
-	responseErr = rh.resultsService.DeleteResult(resultID)
+	note.ResultID = c.Param("id")
+	response, responseErr := rh.resultsService.AddResultNote(c.Request.Context(),
+		user, &note)
 	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh ResultsController) GetResultNotes(c *gin.Context) {
+	user := currentUser(c)
+	response, responseErr := rh.resultsService.GetResultNotes(c.Request.Context(),
+		user, c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
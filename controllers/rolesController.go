@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"encoding/json"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type RolesController struct {
+	rolesService *services.RolesService
+}
+
+func NewRolesController(rolesService *services.RolesService) *RolesController {
+	return &RolesController{
+		rolesService: rolesService,
+	}
+}
+
+func (rh RolesController) GetPermissions(c *gin.Context) {
+	response, responseErr := rh.rolesService.GetPermissions(c.Request.Context())
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh RolesController) GetRoles(c *gin.Context) {
+	response, responseErr := rh.rolesService.GetRoles(c.Request.Context())
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh RolesController) CreateRole(c *gin.Context) {
+	role, ok := readRole(c, "create role")
+	if !ok {
+		return
+	}
+	response, responseErr := rh.rolesService.CreateRole(c.Request.Context(), role)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh RolesController) UpdateRole(c *gin.Context) {
+	role, ok := readRole(c, "update role")
+	if !ok {
+		return
+	}
+	response, responseErr := rh.rolesService.UpdateRole(c.Request.Context(), role)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh RolesController) DeleteRole(c *gin.Context) {
+	responseErr := rh.rolesService.DeleteRole(c.Request.Context(), c.Param("name"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// readRole parses the role in the body of the request, aborting the request
+// when it cannot.
+func readRole(c *gin.Context, request string) (*models.Role, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading "+request+" request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return nil, false
+	}
+	var role models.Role
+	err = json.Unmarshal(body, &role)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling "+request+" request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusBadRequest,
+		})
+		return nil, false
+	}
+	return &role, true
+}
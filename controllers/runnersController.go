@@ -6,154 +6,157 @@ import (
 	"github.com/fentezi/runnerBook/services"
 	"github.com/gin-gonic/gin"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 )
 
-const ROLE_ADMIN = "admin"
-const ROLE_RUNNER = "runner"
-
 type RunnersController struct {
 	runnersService *services.RunnersService
-	usersService   *services.UsersService
 }
 
-func NewRunnersController(runnersService *services.RunnersService, usersService *services.UsersService) *RunnersController {
+func NewRunnersController(runnersService *services.RunnersService) *RunnersController {
 	return &RunnersController{
 		runnersService: runnersService,
-		usersService:   usersService,
 	}
 }
 
 func (rh RunnersController) CreateRunner(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN})
-	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
-		return
-	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
-		return
-	}
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println(
-			"Error while reading create runner request body", err)
-		c.AbortWithError(http.StatusInternalServerError, err)
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading create runner request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
 	var runner models.Runner
 	err = json.Unmarshal(body, &runner)
 	if err != nil {
-		log.Println(
+		slog.ErrorContext(c.Request.Context(),
 			"Error while unmarshaling "+
-				"create runner request body", err)
-		c.AbortWithError(http.StatusInternalServerError, err)
+				"create runner request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
-	response, responseErr := rh.runnersService.CreateRunner(&runner)
+	response, responseErr := rh.runnersService.CreateRunner(c.Request.Context(), &runner)
 	if responseErr != nil {
-		c.AbortWithStatusJSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.JSON(http.StatusOK, response)
 }
 func (rh RunnersController) UpdateRunner(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN})
-	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
-		return
-	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
-		return
-	}
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println(
-			"Error while reading update runner request body", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading update runner request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
 	var runner models.Runner
 	err = json.Unmarshal(body, &runner)
 	if err != nil {
-		log.Println(
+		slog.ErrorContext(c.Request.Context(),
 			"Error while unmarshaling "+
-				"create runner request body", err)
-		c.AbortWithError(http.StatusInternalServerError, err)
+				"create runner request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusInternalServerError,
+		})
 		return
 	}
-	responseErr = rh.runnersService.UpdateRunner(&runner)
+	responseErr := rh.runnersService.UpdateRunner(c.Request.Context(), &runner)
 	if responseErr != nil {
-		c.AbortWithStatusJSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 func (rh RunnersController) DeleteRunner(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN})
-	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
-		return
-	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
-		return
-	}
 	runnerID := c.Param("id")
-	responseErr = rh.runnersService.DeleteRunner(runnerID)
+	responseErr := rh.runnersService.DeleteRunner(c.Request.Context(), runnerID)
 	if responseErr != nil {
-		c.AbortWithStatusJSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 func (rh RunnersController) GetRunner(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN, ROLE_RUNNER})
-	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
-		return
-	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
-		return
-	}
 	runnerID := c.Param("id")
-	response, responseErr := rh.runnersService.GetRunner(runnerID)
+	response, responseErr := rh.runnersService.GetRunner(c.Request.Context(), runnerID)
 	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.JSON(http.StatusOK, response)
 }
 func (rh RunnersController) GetRunnersBatch(c *gin.Context) {
-	accessToken := c.Request.Header.Get("Token")
-	auth, responseErr := rh.usersService.AuthorizeUser(
-		accessToken, []string{ROLE_ADMIN, ROLE_RUNNER})
+	params := c.Request.URL.Query()
+	response, responseErr := rh.runnersService.GetRunnersBatch(c.Request.Context(),
+		services.RunnersBatchQuery{
+			Country:  params.Get("country"),
+			Year:     params.Get("year"),
+			Category: params.Get("category"),
+			AgeGroup: params.Get("age_group"),
+			Ranking:  params.Get("ranking"),
+		})
 	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
-	if !auth {
-		c.Status(http.StatusUnauthorized)
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh RunnersController) CompareRunners(c *gin.Context) {
+	response, responseErr := rh.runnersService.CompareRunners(c.Request.Context(),
+		c.Query("runner_a"), c.Query("runner_b"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
 		return
 	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (rh RunnersController) RecomputeBests(c *gin.Context) {
 	params := c.Request.URL.Query()
-	country := params.Get("country")
-	year := params.Get("year")
-	response, responseErr := rh.runnersService.GetRunnersBatch(country, year)
+	reportOnly := false
+	if params.Get("report_only") != "" {
+		var err error
+		reportOnly, err = strconv.ParseBool(params.Get("report_only"))
+		if err != nil {
+			abortWithError(c, &models.ResponseError{
+				Message: "Invalid report_only",
+				Status:  http.StatusBadRequest,
+			})
+			return
+		}
+	}
+	drifted, responseErr := rh.runnersService.RecomputeBests(
+		c.Request.Context(), params.Get("runner_id"), reportOnly)
 	if responseErr != nil {
-		c.JSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{
+		"report_only": reportOnly,
+		"drifted":     drifted,
+	})
+}
+
+func (rh RunnersController) RolloverSeason(c *gin.Context) {
+	rollover, responseErr := rh.runnersService.RolloverSeason(c.Request.Context())
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, rollover)
 }
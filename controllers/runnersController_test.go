@@ -7,6 +7,7 @@ import (
 	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/repositories"
 	"github.com/fentezi/runnerBook/services"
+	"github.com/fentezi/runnerBook/services/racecalc"
 	"github.com/gin-gonic/gin"
 	"github.com/magiconair/properties/assert"
 	"net/http"
@@ -16,46 +17,110 @@ import (
 
 func initTestRouter(dbHandler *sql.DB) *gin.Engine {
 	runnersRepository := repositories.NewRunnersRepository(dbHandler)
-	runnersService := services.NewRunnersService(runnersRepository, nil)
+	resultsRepository := repositories.NewResultsRepository(dbHandler)
+	usersRepository := repositories.NewUsersRepository(dbHandler)
+	seasonCalendar, _ := services.NewSeasonCalendar("UTC", "01-01")
+	countriesService := services.NewCountriesService(
+		repositories.NewCountriesRepository(dbHandler))
+	runnersService := services.NewRunnersService(runnersRepository,
+		resultsRepository, seasonCalendar, racecalc.DefaultAgeGradingTable(),
+		countriesService)
+	usersService := services.NewUsersService(usersRepository,
+		repositories.NewRolesRepository(dbHandler))
+	authorizer := NewAuthorizer(usersService)
 	runnersController := NewRunnersController(runnersService)
 	router := gin.Default()
-	router.GET("/runner", runnersController.GetRunnersBatch)
-	router.GET("/runner/:id", runnersController.GetRunner)
+	read := authorizer.Require(models.PermissionRunnerRead)
+	router.GET("/runner", read, runnersController.GetRunnersBatch)
+	router.GET("/runner/:id", read, runnersController.GetRunner)
 	return router
 }
 
+func expectAuthorization(mock sqlmock.Sqlmock, role string) {
+	mock.ExpectQuery("SELECT (.+) FROM users").
+		WithArgs("token").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "user_role",
+			"permissions"}).
+			AddRow("1", "user", role, "{"+models.PermissionRunnerRead+"}"))
+}
+
 func TestGetRunner(t *testing.T) {
 	dbHandler, mock, _ := sqlmock.New()
 	defer dbHandler.Close()
+	expectAuthorization(mock, models.RoleRunner)
 	columns := []string{"id", "first_name", "last_name", "age",
-		"is_active", "country", "personal_best", "season_best"}
-	query := `SELECT * FROM runners WHERE id = $1`
-	rows := mock.NewRows(columns).AddRow("1", "John", "Smith", 30, true, "United States", "02:00:41", "02:13:13")
+		"is_active", "country", "personal_best", "season_best",
+		"category", "date_of_birth"}
+	query := `SELECT (.+) FROM runners WHERE id = \$1`
+	rows := mock.NewRows(columns).AddRow("1", "John", "Smith", 30, true, "United States", "02:00:41", "02:13:13", "M", nil)
 	mock.ExpectQuery(query).WithArgs("1").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT (.+) FROM results").WithArgs("1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "race_result", "location", "position", "year", "race_date",
+			"gun_time", "chip_time", "time_precision", "status", "status_reason", "distance",
+			"club_id", "club"}).
+			AddRow("1", "02:00:41", "Berlin", 1, 2023, nil, "02:00:43", "02:00:41", 0, "FIN", nil, 42195,
+				nil, nil))
 	router := initTestRouter(dbHandler)
 	request, _ := http.NewRequest("GET", "/runner/1", nil)
+	request.Header.Set("Token", "token")
 	recorder := httptest.NewRecorder()
 	router.ServeHTTP(recorder, request)
 	t.Log(recorder.Body.String())
 	assert.Equal(t, http.StatusOK, recorder.Result().StatusCode)
-	var runner []*models.Runner
+	var runner *models.Runner
+	json.Unmarshal(recorder.Body.Bytes(), &runner)
+	assert.Equal(t, "1", runner.ID)
+	assert.Equal(t, 1, len(runner.Results))
+}
+
+func TestGetRunnerReturnsAllResults(t *testing.T) {
+	dbHandler, mock, _ := sqlmock.New()
+	defer dbHandler.Close()
+	expectAuthorization(mock, models.RoleRunner)
+	columns := []string{"id", "first_name", "last_name", "age",
+		"is_active", "country", "personal_best", "season_best",
+		"category", "date_of_birth"}
+	mock.ExpectQuery(`SELECT (.+) FROM runners WHERE id = \$1`).WithArgs("1").WillReturnRows(
+		mock.NewRows(columns).AddRow("1", "John", "Smith", 30, true, "United States", "02:00:41", "02:13:13", "M", nil))
+	mock.ExpectQuery("SELECT (.+) FROM results").WithArgs("1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "race_result", "location", "position", "year", "race_date",
+			"gun_time", "chip_time", "time_precision", "status", "status_reason", "distance",
+			"club_id", "club"}).
+			AddRow("1", "02:00:41", "Berlin", 1, 2023, nil, "02:00:43", "02:00:41", 0, "FIN", nil, 42195,
+				nil, nil).
+			AddRow("2", "02:13:13", "London", 3, 2024, nil, "02:13:20", "02:13:13", 0, "FIN", nil, 42195,
+				nil, nil).
+			AddRow("3", "02:05:02", "Chicago", 2, 2024, nil, "02:05:09", "02:05:02", 0, "FIN", nil, 42195,
+				nil, nil))
+	router := initTestRouter(dbHandler)
+	request, _ := http.NewRequest("GET", "/runner/1", nil)
+	request.Header.Set("Token", "token")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	var runner *models.Runner
 	json.Unmarshal(recorder.Body.Bytes(), &runner)
-	assert.Equal(t, 1, len(runner))
+	assert.Equal(t, 3, len(runner.Results))
+	assert.Equal(t, "1", runner.Results[0].ID)
+	assert.Equal(t, "3", runner.Results[2].ID)
 }
 
 func TestGetRunnersResponse(t *testing.T) {
 	dhHandler, mock, _ := sqlmock.New()
 	defer dhHandler.Close()
+	expectAuthorization(mock, models.RoleRunner)
 	columns := []string{"id", "first_name", "last_name", "age",
-		"is_active", "country", "personal_best", "season_best"}
+		"is_active", "country", "personal_best", "season_best",
+		"category", "date_of_birth"}
 	mock.ExpectQuery("SELECT *").WillReturnRows(
 		sqlmock.NewRows(columns).
 			AddRow("1", "John", "Smith", 30, true,
-				"United States", "02:00:41", "02:13:13").
+				"United States", "02:00:41", "02:13:13", "M", nil).
 			AddRow("2", "Marjanna", "Komathic", 24, true,
-				"Serbia", "01:18:28", "01:18:28"))
+				"Serbia", "01:18:28", "01:18:28", "W", nil))
 	router := initTestRouter(dhHandler)
 	request, _ := http.NewRequest("GET", "/runner", nil)
+	request.Header.Set("Token", "token")
 	recorder := httptest.NewRecorder()
 	router.ServeHTTP(recorder, request)
 	assert.Equal(t, http.StatusOK, recorder.Result().StatusCode)
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type SplitsController struct {
+	splitsService *services.SplitsService
+}
+
+func NewSplitsController(splitsService *services.SplitsService) *SplitsController {
+	return &SplitsController{
+		splitsService: splitsService,
+	}
+}
+
+func (sh SplitsController) AddSplits(c *gin.Context) {
+	user := currentUser(c)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while reading add splits request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to read request body",
+			Status:  http.StatusInternalServerError,
+		})
+		return
+	}
+	var splits []*models.Split
+	err = json.Unmarshal(body, &splits)
+	if errors.Is(err, models.ErrInvalidRaceTime) {
+		abortWithError(c, &models.ResponseError{
+			Message: "Invalid race time",
+			Status:  http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(),
+			"Error while unmarshaling add splits request body", "error", err)
+		abortWithError(c, &models.ResponseError{
+			Message: "Failed to parse request body",
+			Status:  http.StatusBadRequest,
+		})
+		return
+	}
+	analysis, responseErr := sh.splitsService.AddSplits(c.Request.Context(),
+		user, c.Param("id"), splits)
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, analysis)
+}
+
+func (sh SplitsController) GetSplits(c *gin.Context) {
+	analysis, responseErr := sh.splitsService.GetSplits(c.Request.Context(),
+		c.Param("id"))
+	if responseErr != nil {
+		abortWithError(c, responseErr)
+		return
+	}
+	c.JSON(http.StatusOK, analysis)
+}
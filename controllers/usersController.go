@@ -1,9 +1,10 @@
 package controllers
 
 import (
+	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/services"
 	"github.com/gin-gonic/gin"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -18,13 +19,16 @@ func NewUsersController(usersService *services.UsersService) *UsersController {
 func (uc UsersController) Login(c *gin.Context) {
 	username, password, ok := c.Request.BasicAuth()
 	if !ok {
-		log.Println("Error while reading credentials")
-		c.AbortWithStatus(http.StatusBadRequest)
+		slog.WarnContext(c.Request.Context(), "Error while reading credentials")
+		abortWithError(c, &models.ResponseError{
+			Message: "Invalid username or password",
+			Status:  http.StatusBadRequest,
+		})
 		return
 	}
-	accessToken, responseErr := uc.usersService.Login(username, password)
+	accessToken, responseErr := uc.usersService.Login(c.Request.Context(), username, password)
 	if responseErr != nil {
-		c.AbortWithStatusJSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.JSON(http.StatusOK, accessToken)
@@ -32,9 +36,9 @@ func (uc UsersController) Login(c *gin.Context) {
 
 func (uc UsersController) Logout(c *gin.Context) {
 	accessToken := c.Request.Header.Get("Token")
-	responseErr := uc.usersService.Logout(accessToken)
+	responseErr := uc.usersService.Logout(c.Request.Context(), accessToken)
 	if responseErr != nil {
-		c.AbortWithStatusJSON(responseErr.Status, responseErr)
+		abortWithError(c, responseErr)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -0,0 +1,83 @@
+package dbscripts
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration scripts are named <version>_<name>.up.sql and
+// <version>_<name>.down.sql and are applied in version order.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns every bundled migration, ordered by version.
+func Migrations() ([]*Migration, error) {
+	fileNames, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, fileName := range fileNames {
+		baseName := strings.TrimPrefix(fileName, "migrations/")
+		versionText, rest, ok := strings.Cut(baseName, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", baseName)
+		}
+		version, err := strconv.Atoi(versionText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", baseName)
+		}
+		content, err := migrationFiles.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			migration.Name = strings.TrimSuffix(rest, ".up.sql")
+			migration.Up = string(content)
+		case strings.HasSuffix(rest, ".down.sql"):
+			migration.Down = string(content)
+		default:
+			return nil, fmt.Errorf("invalid migration file name %s", baseName)
+		}
+	}
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up script",
+				migration.Version)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LatestVersion returns the version of the newest bundled migration, which
+// is the schema version this build expects.
+func LatestVersion() int {
+	migrations, err := Migrations()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
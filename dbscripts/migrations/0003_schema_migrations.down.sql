@@ -0,0 +1 @@
+-- Nothing to undo: schema_migrations is maintained by "runnerbook migrate"
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    integer     NOT NULL,
+    applied_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT schema_migrations_pk PRIMARY KEY (version)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE results
+    ADD COLUMN race_date date;
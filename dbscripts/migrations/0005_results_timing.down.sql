@@ -0,0 +1,4 @@
+ALTER TABLE results
+    DROP COLUMN IF EXISTS gun_time,
+    DROP COLUMN IF EXISTS chip_time,
+    DROP COLUMN IF EXISTS time_precision;
@@ -0,0 +1,4 @@
+ALTER TABLE results
+    ADD COLUMN gun_time       interval,
+    ADD COLUMN chip_time      interval,
+    ADD COLUMN time_precision smallint NOT NULL DEFAULT 0;
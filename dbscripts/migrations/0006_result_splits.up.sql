@@ -0,0 +1,13 @@
+CREATE TABLE result_splits
+(
+    id           uuid     NOT NULL DEFAULT uuid_generate_v1mc(),
+    result_id    uuid     NOT NULL,
+    distance     integer  NOT NULL,
+    elapsed_time interval NOT NULL,
+    CONSTRAINT result_splits_pk PRIMARY KEY (id),
+    CONSTRAINT result_splits_distance UNIQUE (result_id, distance),
+    CONSTRAINT fk_result_splits_result_id FOREIGN KEY (result_id)
+        REFERENCES results (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
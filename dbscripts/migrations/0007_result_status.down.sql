@@ -0,0 +1,9 @@
+-- Results without a time cannot be kept once race_result is required again.
+DELETE
+FROM results
+WHERE race_result IS NULL;
+ALTER TABLE results
+    ALTER COLUMN race_result SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS results_status,
+    DROP COLUMN IF EXISTS status_reason,
+    DROP COLUMN IF EXISTS status;
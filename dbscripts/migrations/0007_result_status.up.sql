@@ -0,0 +1,5 @@
+ALTER TABLE results
+    ADD COLUMN status        text NOT NULL DEFAULT 'FIN',
+    ADD COLUMN status_reason text,
+    ADD CONSTRAINT results_status CHECK (status IN ('FIN', 'DNF', 'DNS', 'DQ')),
+    ALTER COLUMN race_result DROP NOT NULL;
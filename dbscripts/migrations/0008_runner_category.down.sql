@@ -0,0 +1,4 @@
+ALTER TABLE runners
+    DROP CONSTRAINT IF EXISTS runners_category,
+    DROP COLUMN IF EXISTS date_of_birth,
+    DROP COLUMN IF EXISTS category;
@@ -0,0 +1,4 @@
+ALTER TABLE runners
+    ADD COLUMN category      text,
+    ADD COLUMN date_of_birth date,
+    ADD CONSTRAINT runners_category CHECK (category IN ('M', 'W', 'X'));
@@ -0,0 +1,2 @@
+ALTER TABLE results
+    ADD COLUMN distance integer NOT NULL DEFAULT 42195;
@@ -0,0 +1,16 @@
+CREATE TABLE ratings
+(
+    runner_id uuid             NOT NULL,
+    distance  integer          NOT NULL,
+    race      text             NOT NULL,
+    race_date date             NOT NULL,
+    rating    double precision NOT NULL,
+    races     integer          NOT NULL,
+    CONSTRAINT ratings_pk PRIMARY KEY (runner_id, distance, race_date, race),
+    CONSTRAINT fk_ratings_runner_id FOREIGN KEY (runner_id)
+        REFERENCES runners (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE INDEX ratings_distance_race_date
+    ON ratings (distance, race_date);
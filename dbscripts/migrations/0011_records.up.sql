@@ -0,0 +1,20 @@
+CREATE TABLE records
+(
+    id            uuid        NOT NULL DEFAULT uuid_generate_v1mc(),
+    country       text        NOT NULL DEFAULT '',
+    category      text        NOT NULL DEFAULT '',
+    distance      integer     NOT NULL,
+    result_id     uuid        NOT NULL,
+    race_result   interval    NOT NULL,
+    previous_id   uuid,
+    current       boolean     NOT NULL DEFAULT TRUE,
+    recognized_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT records_pk PRIMARY KEY (id),
+    CONSTRAINT fk_records_result_id FOREIGN KEY (result_id)
+        REFERENCES results (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX records_current
+    ON records (country, category, distance)
+    WHERE current;
@@ -0,0 +1,7 @@
+-- Runners and records keep their alpha-3 codes: the free-text spellings
+-- they replaced are not restored.
+ALTER TABLE runners
+    DROP CONSTRAINT IF EXISTS fk_runners_country;
+DROP VIEW IF EXISTS country_names;
+DROP TABLE IF EXISTS country_aliases;
+DROP TABLE IF EXISTS countries;
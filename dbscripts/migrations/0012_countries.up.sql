@@ -0,0 +1,369 @@
+CREATE TABLE countries
+(
+    alpha2 text NOT NULL,
+    alpha3 text NOT NULL,
+    name   text NOT NULL,
+    CONSTRAINT countries_pk PRIMARY KEY (alpha3),
+    CONSTRAINT countries_alpha2 UNIQUE (alpha2),
+    CONSTRAINT countries_alpha2_code CHECK (alpha2 ~ '^[A-Z]{2}$'),
+    CONSTRAINT countries_alpha3_code CHECK (alpha3 ~ '^[A-Z]{3}$')
+);
+CREATE TABLE country_aliases
+(
+    alias  text NOT NULL,
+    alpha3 text NOT NULL,
+    CONSTRAINT country_aliases_pk PRIMARY KEY (alias),
+    CONSTRAINT fk_country_aliases_alpha3 FOREIGN KEY (alpha3)
+        REFERENCES countries (alpha3) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+-- ISO 3166-1, with common English short names.
+INSERT INTO countries (alpha2, alpha3, name)
+VALUES ('AF', 'AFG', 'Afghanistan'),
+       ('AX', 'ALA', 'Åland Islands'),
+       ('AL', 'ALB', 'Albania'),
+       ('DZ', 'DZA', 'Algeria'),
+       ('AS', 'ASM', 'American Samoa'),
+       ('AD', 'AND', 'Andorra'),
+       ('AO', 'AGO', 'Angola'),
+       ('AI', 'AIA', 'Anguilla'),
+       ('AQ', 'ATA', 'Antarctica'),
+       ('AG', 'ATG', 'Antigua and Barbuda'),
+       ('AR', 'ARG', 'Argentina'),
+       ('AM', 'ARM', 'Armenia'),
+       ('AW', 'ABW', 'Aruba'),
+       ('AU', 'AUS', 'Australia'),
+       ('AT', 'AUT', 'Austria'),
+       ('AZ', 'AZE', 'Azerbaijan'),
+       ('BS', 'BHS', 'Bahamas'),
+       ('BH', 'BHR', 'Bahrain'),
+       ('BD', 'BGD', 'Bangladesh'),
+       ('BB', 'BRB', 'Barbados'),
+       ('BY', 'BLR', 'Belarus'),
+       ('BE', 'BEL', 'Belgium'),
+       ('BZ', 'BLZ', 'Belize'),
+       ('BJ', 'BEN', 'Benin'),
+       ('BM', 'BMU', 'Bermuda'),
+       ('BT', 'BTN', 'Bhutan'),
+       ('BO', 'BOL', 'Bolivia'),
+       ('BQ', 'BES', 'Bonaire, Sint Eustatius and Saba'),
+       ('BA', 'BIH', 'Bosnia and Herzegovina'),
+       ('BW', 'BWA', 'Botswana'),
+       ('BV', 'BVT', 'Bouvet Island'),
+       ('BR', 'BRA', 'Brazil'),
+       ('IO', 'IOT', 'British Indian Ocean Territory'),
+       ('BN', 'BRN', 'Brunei Darussalam'),
+       ('BG', 'BGR', 'Bulgaria'),
+       ('BF', 'BFA', 'Burkina Faso'),
+       ('BI', 'BDI', 'Burundi'),
+       ('CV', 'CPV', 'Cabo Verde'),
+       ('KH', 'KHM', 'Cambodia'),
+       ('CM', 'CMR', 'Cameroon'),
+       ('CA', 'CAN', 'Canada'),
+       ('KY', 'CYM', 'Cayman Islands'),
+       ('CF', 'CAF', 'Central African Republic'),
+       ('TD', 'TCD', 'Chad'),
+       ('CL', 'CHL', 'Chile'),
+       ('CN', 'CHN', 'China'),
+       ('CX', 'CXR', 'Christmas Island'),
+       ('CC', 'CCK', 'Cocos (Keeling) Islands'),
+       ('CO', 'COL', 'Colombia'),
+       ('KM', 'COM', 'Comoros'),
+       ('CG', 'COG', 'Congo'),
+       ('CD', 'COD', 'Congo, Democratic Republic of the'),
+       ('CK', 'COK', 'Cook Islands'),
+       ('CR', 'CRI', 'Costa Rica'),
+       ('CI', 'CIV', 'Côte d''Ivoire'),
+       ('HR', 'HRV', 'Croatia'),
+       ('CU', 'CUB', 'Cuba'),
+       ('CW', 'CUW', 'Curaçao'),
+       ('CY', 'CYP', 'Cyprus'),
+       ('CZ', 'CZE', 'Czechia'),
+       ('DK', 'DNK', 'Denmark'),
+       ('DJ', 'DJI', 'Djibouti'),
+       ('DM', 'DMA', 'Dominica'),
+       ('DO', 'DOM', 'Dominican Republic'),
+       ('EC', 'ECU', 'Ecuador'),
+       ('EG', 'EGY', 'Egypt'),
+       ('SV', 'SLV', 'El Salvador'),
+       ('GQ', 'GNQ', 'Equatorial Guinea'),
+       ('ER', 'ERI', 'Eritrea'),
+       ('EE', 'EST', 'Estonia'),
+       ('SZ', 'SWZ', 'Eswatini'),
+       ('ET', 'ETH', 'Ethiopia'),
+       ('FK', 'FLK', 'Falkland Islands (Malvinas)'),
+       ('FO', 'FRO', 'Faroe Islands'),
+       ('FJ', 'FJI', 'Fiji'),
+       ('FI', 'FIN', 'Finland'),
+       ('FR', 'FRA', 'France'),
+       ('GF', 'GUF', 'French Guiana'),
+       ('PF', 'PYF', 'French Polynesia'),
+       ('TF', 'ATF', 'French Southern Territories'),
+       ('GA', 'GAB', 'Gabon'),
+       ('GM', 'GMB', 'Gambia'),
+       ('GE', 'GEO', 'Georgia'),
+       ('DE', 'DEU', 'Germany'),
+       ('GH', 'GHA', 'Ghana'),
+       ('GI', 'GIB', 'Gibraltar'),
+       ('GR', 'GRC', 'Greece'),
+       ('GL', 'GRL', 'Greenland'),
+       ('GD', 'GRD', 'Grenada'),
+       ('GP', 'GLP', 'Guadeloupe'),
+       ('GU', 'GUM', 'Guam'),
+       ('GT', 'GTM', 'Guatemala'),
+       ('GG', 'GGY', 'Guernsey'),
+       ('GN', 'GIN', 'Guinea'),
+       ('GW', 'GNB', 'Guinea-Bissau'),
+       ('GY', 'GUY', 'Guyana'),
+       ('HT', 'HTI', 'Haiti'),
+       ('HM', 'HMD', 'Heard Island and McDonald Islands'),
+       ('VA', 'VAT', 'Holy See'),
+       ('HN', 'HND', 'Honduras'),
+       ('HK', 'HKG', 'Hong Kong'),
+       ('HU', 'HUN', 'Hungary'),
+       ('IS', 'ISL', 'Iceland'),
+       ('IN', 'IND', 'India'),
+       ('ID', 'IDN', 'Indonesia'),
+       ('IR', 'IRN', 'Iran'),
+       ('IQ', 'IRQ', 'Iraq'),
+       ('IE', 'IRL', 'Ireland'),
+       ('IM', 'IMN', 'Isle of Man'),
+       ('IL', 'ISR', 'Israel'),
+       ('IT', 'ITA', 'Italy'),
+       ('JM', 'JAM', 'Jamaica'),
+       ('JP', 'JPN', 'Japan'),
+       ('JE', 'JEY', 'Jersey'),
+       ('JO', 'JOR', 'Jordan'),
+       ('KZ', 'KAZ', 'Kazakhstan'),
+       ('KE', 'KEN', 'Kenya'),
+       ('KI', 'KIR', 'Kiribati'),
+       ('KP', 'PRK', 'North Korea'),
+       ('KR', 'KOR', 'South Korea'),
+       ('KW', 'KWT', 'Kuwait'),
+       ('KG', 'KGZ', 'Kyrgyzstan'),
+       ('LA', 'LAO', 'Laos'),
+       ('LV', 'LVA', 'Latvia'),
+       ('LB', 'LBN', 'Lebanon'),
+       ('LS', 'LSO', 'Lesotho'),
+       ('LR', 'LBR', 'Liberia'),
+       ('LY', 'LBY', 'Libya'),
+       ('LI', 'LIE', 'Liechtenstein'),
+       ('LT', 'LTU', 'Lithuania'),
+       ('LU', 'LUX', 'Luxembourg'),
+       ('MO', 'MAC', 'Macao'),
+       ('MG', 'MDG', 'Madagascar'),
+       ('MW', 'MWI', 'Malawi'),
+       ('MY', 'MYS', 'Malaysia'),
+       ('MV', 'MDV', 'Maldives'),
+       ('ML', 'MLI', 'Mali'),
+       ('MT', 'MLT', 'Malta'),
+       ('MH', 'MHL', 'Marshall Islands'),
+       ('MQ', 'MTQ', 'Martinique'),
+       ('MR', 'MRT', 'Mauritania'),
+       ('MU', 'MUS', 'Mauritius'),
+       ('YT', 'MYT', 'Mayotte'),
+       ('MX', 'MEX', 'Mexico'),
+       ('FM', 'FSM', 'Micronesia'),
+       ('MD', 'MDA', 'Moldova'),
+       ('MC', 'MCO', 'Monaco'),
+       ('MN', 'MNG', 'Mongolia'),
+       ('ME', 'MNE', 'Montenegro'),
+       ('MS', 'MSR', 'Montserrat'),
+       ('MA', 'MAR', 'Morocco'),
+       ('MZ', 'MOZ', 'Mozambique'),
+       ('MM', 'MMR', 'Myanmar'),
+       ('NA', 'NAM', 'Namibia'),
+       ('NR', 'NRU', 'Nauru'),
+       ('NP', 'NPL', 'Nepal'),
+       ('NL', 'NLD', 'Netherlands'),
+       ('NC', 'NCL', 'New Caledonia'),
+       ('NZ', 'NZL', 'New Zealand'),
+       ('NI', 'NIC', 'Nicaragua'),
+       ('NE', 'NER', 'Niger'),
+       ('NG', 'NGA', 'Nigeria'),
+       ('NU', 'NIU', 'Niue'),
+       ('NF', 'NFK', 'Norfolk Island'),
+       ('MK', 'MKD', 'North Macedonia'),
+       ('MP', 'MNP', 'Northern Mariana Islands'),
+       ('NO', 'NOR', 'Norway'),
+       ('OM', 'OMN', 'Oman'),
+       ('PK', 'PAK', 'Pakistan'),
+       ('PW', 'PLW', 'Palau'),
+       ('PS', 'PSE', 'Palestine, State of'),
+       ('PA', 'PAN', 'Panama'),
+       ('PG', 'PNG', 'Papua New Guinea'),
+       ('PY', 'PRY', 'Paraguay'),
+       ('PE', 'PER', 'Peru'),
+       ('PH', 'PHL', 'Philippines'),
+       ('PN', 'PCN', 'Pitcairn'),
+       ('PL', 'POL', 'Poland'),
+       ('PT', 'PRT', 'Portugal'),
+       ('PR', 'PRI', 'Puerto Rico'),
+       ('QA', 'QAT', 'Qatar'),
+       ('RE', 'REU', 'Réunion'),
+       ('RO', 'ROU', 'Romania'),
+       ('RU', 'RUS', 'Russia'),
+       ('RW', 'RWA', 'Rwanda'),
+       ('BL', 'BLM', 'Saint Barthélemy'),
+       ('SH', 'SHN', 'Saint Helena, Ascension and Tristan da Cunha'),
+       ('KN', 'KNA', 'Saint Kitts and Nevis'),
+       ('LC', 'LCA', 'Saint Lucia'),
+       ('MF', 'MAF', 'Saint Martin (French part)'),
+       ('PM', 'SPM', 'Saint Pierre and Miquelon'),
+       ('VC', 'VCT', 'Saint Vincent and the Grenadines'),
+       ('WS', 'WSM', 'Samoa'),
+       ('SM', 'SMR', 'San Marino'),
+       ('ST', 'STP', 'Sao Tome and Principe'),
+       ('SA', 'SAU', 'Saudi Arabia'),
+       ('SN', 'SEN', 'Senegal'),
+       ('RS', 'SRB', 'Serbia'),
+       ('SC', 'SYC', 'Seychelles'),
+       ('SL', 'SLE', 'Sierra Leone'),
+       ('SG', 'SGP', 'Singapore'),
+       ('SX', 'SXM', 'Sint Maarten (Dutch part)'),
+       ('SK', 'SVK', 'Slovakia'),
+       ('SI', 'SVN', 'Slovenia'),
+       ('SB', 'SLB', 'Solomon Islands'),
+       ('SO', 'SOM', 'Somalia'),
+       ('ZA', 'ZAF', 'South Africa'),
+       ('GS', 'SGS', 'South Georgia and the South Sandwich Islands'),
+       ('SS', 'SSD', 'South Sudan'),
+       ('ES', 'ESP', 'Spain'),
+       ('LK', 'LKA', 'Sri Lanka'),
+       ('SD', 'SDN', 'Sudan'),
+       ('SR', 'SUR', 'Suriname'),
+       ('SJ', 'SJM', 'Svalbard and Jan Mayen'),
+       ('SE', 'SWE', 'Sweden'),
+       ('CH', 'CHE', 'Switzerland'),
+       ('SY', 'SYR', 'Syria'),
+       ('TW', 'TWN', 'Taiwan'),
+       ('TJ', 'TJK', 'Tajikistan'),
+       ('TZ', 'TZA', 'Tanzania'),
+       ('TH', 'THA', 'Thailand'),
+       ('TL', 'TLS', 'Timor-Leste'),
+       ('TG', 'TGO', 'Togo'),
+       ('TK', 'TKL', 'Tokelau'),
+       ('TO', 'TON', 'Tonga'),
+       ('TT', 'TTO', 'Trinidad and Tobago'),
+       ('TN', 'TUN', 'Tunisia'),
+       ('TR', 'TUR', 'Türkiye'),
+       ('TM', 'TKM', 'Turkmenistan'),
+       ('TC', 'TCA', 'Turks and Caicos Islands'),
+       ('TV', 'TUV', 'Tuvalu'),
+       ('UG', 'UGA', 'Uganda'),
+       ('UA', 'UKR', 'Ukraine'),
+       ('AE', 'ARE', 'United Arab Emirates'),
+       ('GB', 'GBR', 'United Kingdom'),
+       ('US', 'USA', 'United States'),
+       ('UM', 'UMI', 'United States Minor Outlying Islands'),
+       ('UY', 'URY', 'Uruguay'),
+       ('UZ', 'UZB', 'Uzbekistan'),
+       ('VU', 'VUT', 'Vanuatu'),
+       ('VE', 'VEN', 'Venezuela'),
+       ('VN', 'VNM', 'Viet Nam'),
+       ('VG', 'VGB', 'Virgin Islands (British)'),
+       ('VI', 'VIR', 'Virgin Islands (U.S.)'),
+       ('WF', 'WLF', 'Wallis and Futuna'),
+       ('EH', 'ESH', 'Western Sahara'),
+       ('YE', 'YEM', 'Yemen'),
+       ('ZM', 'ZMB', 'Zambia'),
+       ('ZW', 'ZWE', 'Zimbabwe');
+INSERT INTO country_aliases (alias, alpha3)
+VALUES ('United States of America', 'USA'),
+       ('America', 'USA'),
+       ('U.S.', 'USA'),
+       ('U.S.A.', 'USA'),
+       ('UK', 'GBR'),
+       ('Great Britain', 'GBR'),
+       ('Britain', 'GBR'),
+       ('England', 'GBR'),
+       ('Scotland', 'GBR'),
+       ('Wales', 'GBR'),
+       ('Northern Ireland', 'GBR'),
+       ('United Kingdom of Great Britain and Northern Ireland', 'GBR'),
+       ('Russian Federation', 'RUS'),
+       ('Korea', 'KOR'),
+       ('Republic of Korea', 'KOR'),
+       ('Korea, Republic of', 'KOR'),
+       ('Democratic People''s Republic of Korea', 'PRK'),
+       ('Korea, Democratic People''s Republic of', 'PRK'),
+       ('Iran, Islamic Republic of', 'IRN'),
+       ('Syrian Arab Republic', 'SYR'),
+       ('Lao People''s Democratic Republic', 'LAO'),
+       ('Bolivia, Plurinational State of', 'BOL'),
+       ('Venezuela, Bolivarian Republic of', 'VEN'),
+       ('Tanzania, United Republic of', 'TZA'),
+       ('United Republic of Tanzania', 'TZA'),
+       ('Moldova, Republic of', 'MDA'),
+       ('Republic of Moldova', 'MDA'),
+       ('Vietnam', 'VNM'),
+       ('Czech Republic', 'CZE'),
+       ('Ivory Coast', 'CIV'),
+       ('Cote d''Ivoire', 'CIV'),
+       ('Cape Verde', 'CPV'),
+       ('Swaziland', 'SWZ'),
+       ('Macedonia', 'MKD'),
+       ('Burma', 'MMR'),
+       ('Turkey', 'TUR'),
+       ('Holland', 'NLD'),
+       ('The Netherlands', 'NLD'),
+       ('Brunei', 'BRN'),
+       ('Micronesia, Federated States of', 'FSM'),
+       ('DR Congo', 'COD'),
+       ('Democratic Republic of the Congo', 'COD'),
+       ('Republic of the Congo', 'COG'),
+       ('Palestine', 'PSE'),
+       ('Vatican', 'VAT'),
+       ('Vatican City', 'VAT'),
+       ('Taiwan, Province of China', 'TWN'),
+       ('Chinese Taipei', 'TWN'),
+       ('Macau', 'MAC'),
+       ('East Timor', 'TLS'),
+       ('Curacao', 'CUW'),
+       ('Reunion', 'REU'),
+       ('São Tomé and Príncipe', 'STP'),
+       ('Aland Islands', 'ALA'),
+       ('The Bahamas', 'BHS'),
+       ('The Gambia', 'GMB'),
+       ('UAE', 'ARE');
+-- country_names maps every accepted spelling of a country, lowercased, to
+-- its alpha-3 code.
+CREATE VIEW country_names AS
+SELECT lower(alpha2) AS name, alpha3 FROM countries
+UNION
+SELECT lower(alpha3), alpha3 FROM countries
+UNION
+SELECT lower(name), alpha3 FROM countries
+UNION
+SELECT lower(alias), alpha3 FROM country_aliases;
+-- Countries are stored as alpha-3 codes. Values that name no known country
+-- are left as they are and are to be corrected by hand: the foreign key only
+-- checks new and updated runners until it is validated.
+UPDATE runners
+SET country = country_names.alpha3
+FROM country_names
+WHERE country_names.name = lower(trim(runners.country));
+ALTER TABLE runners
+    ADD CONSTRAINT fk_runners_country FOREIGN KEY (country)
+        REFERENCES countries (alpha3) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+        NOT VALID;
+-- National records held under several spellings of a country merge: the
+-- fastest stays current.
+UPDATE records
+SET current = FALSE
+WHERE current
+  AND id NOT IN (
+    SELECT DISTINCT ON (COALESCE(country_names.alpha3, records.country),
+                        records.category, records.distance) records.id
+    FROM records
+    LEFT JOIN country_names ON country_names.name = lower(trim(records.country))
+    WHERE records.current
+    ORDER BY COALESCE(country_names.alpha3, records.country), records.category,
+             records.distance, records.race_result, records.recognized_at);
+UPDATE records
+SET country = country_names.alpha3
+FROM country_names
+WHERE country_names.name = lower(trim(records.country));
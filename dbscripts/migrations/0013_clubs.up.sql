@@ -0,0 +1,37 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+CREATE TABLE clubs
+(
+    id      uuid NOT NULL DEFAULT uuid_generate_v1mc(),
+    name    text NOT NULL,
+    country text,
+    CONSTRAINT clubs_pk PRIMARY KEY (id),
+    CONSTRAINT clubs_name UNIQUE (name),
+    CONSTRAINT fk_clubs_country FOREIGN KEY (country)
+        REFERENCES countries (alpha3) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+-- A membership runs from joined_on up to, but not including, left_on, and is
+-- open while left_on is NULL. A runner belongs to one club at a time.
+CREATE TABLE club_memberships
+(
+    id        uuid NOT NULL DEFAULT uuid_generate_v1mc(),
+    club_id   uuid NOT NULL,
+    runner_id uuid NOT NULL,
+    joined_on date NOT NULL,
+    left_on   date,
+    CONSTRAINT club_memberships_pk PRIMARY KEY (id),
+    CONSTRAINT club_memberships_dates CHECK (left_on > joined_on),
+    CONSTRAINT club_memberships_overlap EXCLUDE USING gist (
+        runner_id WITH =, daterange(joined_on, left_on) WITH &&),
+    CONSTRAINT fk_club_memberships_club_id FOREIGN KEY (club_id)
+        REFERENCES clubs (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_club_memberships_runner_id FOREIGN KEY (runner_id)
+        REFERENCES runners (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE INDEX club_memberships_club_id
+    ON club_memberships (club_id);
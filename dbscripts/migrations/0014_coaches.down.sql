@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS result_notes;
+DROP TABLE IF EXISTS coach_athletes;
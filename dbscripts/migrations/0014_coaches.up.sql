@@ -0,0 +1,34 @@
+CREATE TABLE coach_athletes
+(
+    coach_id    uuid        NOT NULL,
+    runner_id   uuid        NOT NULL,
+    assigned_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT coach_athletes_pk PRIMARY KEY (coach_id, runner_id),
+    CONSTRAINT fk_coach_athletes_coach_id FOREIGN KEY (coach_id)
+        REFERENCES users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_coach_athletes_runner_id FOREIGN KEY (runner_id)
+        REFERENCES runners (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+CREATE TABLE result_notes
+(
+    id         uuid        NOT NULL DEFAULT uuid_generate_v1mc(),
+    result_id  uuid        NOT NULL,
+    author_id  uuid,
+    note       text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT result_notes_pk PRIMARY KEY (id),
+    CONSTRAINT fk_result_notes_result_id FOREIGN KEY (result_id)
+        REFERENCES results (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_result_notes_author_id FOREIGN KEY (author_id)
+        REFERENCES users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+CREATE INDEX result_notes_result_id
+    ON result_notes (result_id);
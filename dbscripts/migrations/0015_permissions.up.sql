@@ -0,0 +1,56 @@
+CREATE TABLE roles
+(
+    name text NOT NULL,
+    CONSTRAINT roles_pk PRIMARY KEY (name)
+);
+CREATE TABLE permissions
+(
+    name        text NOT NULL,
+    description text NOT NULL,
+    CONSTRAINT permissions_pk PRIMARY KEY (name)
+);
+CREATE TABLE role_permissions
+(
+    role       text NOT NULL,
+    permission text NOT NULL,
+    CONSTRAINT role_permissions_pk PRIMARY KEY (role, permission),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role)
+        REFERENCES roles (name) MATCH SIMPLE
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission)
+        REFERENCES permissions (name) MATCH SIMPLE
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+INSERT INTO permissions (name, description)
+VALUES ('runner:read', 'View runners, results, rankings, records and clubs'),
+       ('runner:write', 'Create, update and delete runners'),
+       ('runner:any', 'Act for every runner rather than for assigned athletes only'),
+       ('result:write', 'Submit results and splits'),
+       ('result:delete', 'Delete results'),
+       ('result:annotate', 'Read and write result notes'),
+       ('club:manage', 'Manage clubs and memberships'),
+       ('coach:manage', 'Assign athletes to coaches and view any coach''s athletes'),
+       ('season:manage', 'Recompute bests and ratings and roll the season over'),
+       ('user:manage', 'Manage roles and their permissions');
+INSERT INTO roles (name)
+VALUES ('admin'),
+       ('runner'),
+       ('coach');
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name
+FROM permissions;
+INSERT INTO role_permissions (role, permission)
+VALUES ('runner', 'runner:read'),
+       ('coach', 'runner:read'),
+       ('coach', 'result:write'),
+       ('coach', 'result:annotate');
+-- Users whose role is unknown keep it until it is changed: the foreign key
+-- only checks new and updated users until it is validated.
+ALTER TABLE users
+    ADD CONSTRAINT fk_users_role FOREIGN KEY (user_role)
+        REFERENCES roles (name) MATCH SIMPLE
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+        NOT VALID;
@@ -0,0 +1,17 @@
+package dbscripts
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestMigrationsAreContiguousAndReversible(t *testing.T) {
+	migrations, err := Migrations()
+	assert.Equal(t, nil, err)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version)
+		assert.Equal(t, true, migration.Name != "")
+		assert.Equal(t, true, migration.Down != "")
+	}
+	assert.Equal(t, len(migrations), LatestVersion())
+}
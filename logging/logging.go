@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never written to the
+// log, whatever the level.
+var sensitiveKeys = []string{
+	"password", "token", "authorization", "secret", "connecting_string",
+}
+
+// Level is the level of the default logger. It can be changed at runtime.
+var Level = new(slog.LevelVar)
+
+// InitLogger installs a structured default logger configured by
+// logging.format ("json" or "text") and logging.level ("debug", "info",
+// "warn" or "error"). Every record logged with a context carries the request
+// ID stored in that context.
+func InitLogger(config *viper.Viper) (*slog.Logger, error) {
+	return initLogger(config, os.Stderr)
+}
+
+func initLogger(config *viper.Viper, output io.Writer) (*slog.Logger, error) {
+	level, err := ParseLevel(config.GetString("logging.level"))
+	if err != nil {
+		return nil, err
+	}
+	Level.Set(level)
+	options := &slog.HandlerOptions{
+		Level:       Level,
+		ReplaceAttr: redactSensitive,
+	}
+	var handler slog.Handler
+	switch strings.ToLower(config.GetString("logging.format")) {
+	case "", "json":
+		handler = slog.NewJSONHandler(output, options)
+	case "text":
+		handler = slog.NewTextHandler(output, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q",
+			config.GetString("logging.format"))
+	}
+	logger := slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// ParseLevel parses a level name. An empty name means info.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(name))
+	if err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+func redactSensitive(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	return attr
+}
+
+// contextHandler adds the request ID found in the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
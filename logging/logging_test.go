@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggerAddsRequestIDAndRedactsSecrets(t *testing.T) {
+	config := viper.New()
+	config.Set("logging.format", "json")
+	var output bytes.Buffer
+	logger, err := initLogger(config, &output)
+	assert.Equal(t, nil, err)
+	ctx := WithRequestID(context.Background(), "abc123")
+	logger.InfoContext(ctx, "Login", "username", "admin",
+		"password", "hunter2", "access_token", "secret-token")
+	var record map[string]any
+	err = json.Unmarshal(output.Bytes(), &record)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "abc123", record["request_id"])
+	assert.Equal(t, "admin", record["username"])
+	assert.Equal(t, redacted, record["password"])
+	assert.Equal(t, redacted, record["access_token"])
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "Default", level: "", want: slog.LevelInfo},
+		{name: "Debug", level: "debug", want: slog.LevelDebug},
+		{name: "Upper_Case", level: "WARN", want: slog.LevelWarn},
+		{name: "Unknown", level: "verbose", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := ParseLevel(test.level)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, level)
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.Equal(t, true, validRequestID("3f2a-41"))
+	assert.Equal(t, false, validRequestID(""))
+	assert.Equal(t, false, validRequestID("forged\nline"))
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "runnerbook"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	databasePoolSettings = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "database_pool_setting",
+		Help:      "Configured database connection pool limits.",
+	}, []string{"setting"})
+
+	ResultsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "results_created_total",
+		Help:      "Number of race results created.",
+	})
+	PersonalBestsSet = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "personal_bests_set_total",
+		Help:      "Number of results that set a new personal best.",
+	})
+	SeasonBestsSet = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "season_bests_set_total",
+		Help:      "Number of results that set a new season best.",
+	})
+	RecordsSet = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "records_set_total",
+		Help:      "Number of records set, counting every scope a result sets one in.",
+	})
+	LoginFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Number of rejected login attempts.",
+	})
+)
+
+// RegisterDatabase exports the sql.DBStats of dbHandler together with the
+// configured pool limits, so that pool exhaustion shows up next to the
+// settings that cause it.
+func RegisterDatabase(dbHandler *sql.DB, dbName string,
+	maxOpenConnections, maxIdleConnections int) {
+	err := prometheus.Register(collectors.NewDBStatsCollector(dbHandler, dbName))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &alreadyRegistered) {
+		panic(err)
+	}
+	databasePoolSettings.WithLabelValues("max_open_connections").
+		Set(float64(maxOpenConnections))
+	databasePoolSettings.WithLabelValues("max_idle_connections").
+		Set(float64(maxIdleConnections))
+}
+
+// Middleware records the count and latency of every request. Requests that
+// do not match a route are grouped under "unmatched" to keep label
+// cardinality bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
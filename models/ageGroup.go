@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Competition categories of runners.
+const (
+	CategoryMen       = "M"
+	CategoryWomen     = "W"
+	CategoryNonBinary = "X"
+)
+
+// Age group boundaries: juniors are under juniorAge, masters groups span
+// mastersGroupSize years from mastersAge and everyone else is a senior.
+const (
+	juniorAge        = 20
+	mastersAge       = 35
+	mastersGroupSize = 5
+)
+
+var ErrInvalidAgeGroup = errors.New("invalid age group")
+
+// ValidCategory reports whether category is a competition category.
+func ValidCategory(category string) bool {
+	switch category {
+	case CategoryMen, CategoryWomen, CategoryNonBinary:
+		return true
+	}
+	return false
+}
+
+// AgeGroup returns the age group of a runner in category at age, e.g. "WU20"
+// for women under 20, "MSEN" for men from 20 to 34 and "M40" for men from 40
+// to 44.
+func AgeGroup(category string, age int) string {
+	switch {
+	case age < juniorAge:
+		return category + "U" + strconv.Itoa(juniorAge)
+	case age < mastersAge:
+		return category + "SEN"
+	}
+	return category + strconv.Itoa(age-age%mastersGroupSize)
+}
+
+// ParseAgeGroup returns the category of an age group and the inclusive range
+// of ages in it.
+func ParseAgeGroup(group string) (category string, minAge, maxAge int, err error) {
+	if group == "" {
+		return "", 0, 0, ErrInvalidAgeGroup
+	}
+	category, band := group[:1], group[1:]
+	if !ValidCategory(category) {
+		return "", 0, 0, fmt.Errorf("%w %q", ErrInvalidAgeGroup, group)
+	}
+	switch band {
+	case "U" + strconv.Itoa(juniorAge):
+		return category, 0, juniorAge - 1, nil
+	case "SEN":
+		return category, juniorAge, mastersAge - 1, nil
+	}
+	minAge, err = strconv.Atoi(band)
+	if err != nil || strings.HasPrefix(band, "+") || minAge < mastersAge ||
+		minAge%mastersGroupSize != 0 {
+		return "", 0, 0, fmt.Errorf("%w %q", ErrInvalidAgeGroup, group)
+	}
+	return category, minAge, minAge + mastersGroupSize - 1, nil
+}
+
+// AgeOn returns the age on date of someone born on dateOfBirth.
+func AgeOn(dateOfBirth, date time.Time) int {
+	age := date.Year() - dateOfBirth.Year()
+	if date.Month() < dateOfBirth.Month() ||
+		date.Month() == dateOfBirth.Month() && date.Day() < dateOfBirth.Day() {
+		age--
+	}
+	return age
+}
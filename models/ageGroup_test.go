@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestAgeGroup(t *testing.T) {
+	tests := []struct {
+		category string
+		age      int
+		want     string
+		minAge   int
+		maxAge   int
+	}{
+		{CategoryWomen, 17, "WU20", 0, 19},
+		{CategoryMen, 20, "MSEN", 20, 34},
+		{CategoryMen, 34, "MSEN", 20, 34},
+		{CategoryWomen, 35, "W35", 35, 39},
+		{CategoryMen, 44, "M40", 40, 44},
+		{CategoryNonBinary, 71, "X70", 70, 74},
+	}
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, AgeGroup(test.category, test.age))
+			category, minAge, maxAge, err := ParseAgeGroup(test.want)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, test.category, category)
+			assert.Equal(t, test.minAge, minAge)
+			assert.Equal(t, test.maxAge, maxAge)
+		})
+	}
+}
+
+func TestParseAgeGroupErrors(t *testing.T) {
+	for _, group := range []string{"", "M", "Q40", "M30", "M42", "MU18", "M+40", "W-5"} {
+		t.Run(group, func(t *testing.T) {
+			_, _, _, err := ParseAgeGroup(group)
+			assert.Equal(t, true, err != nil)
+		})
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	dateOfBirth := time.Date(1984, time.March, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 39, AgeOn(dateOfBirth, time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 40, AgeOn(dateOfBirth, time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 40, AgeOn(dateOfBirth, time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)))
+}
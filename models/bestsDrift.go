@@ -0,0 +1,13 @@
+package models
+
+// BestsDrift describes a runner whose stored personal or season best differs
+// from the value computed from the results table.
+type BestsDrift struct {
+	RunnerID           string   `json:"runner_id"`
+	FirstName          string   `json:"first_name"`
+	LastName           string   `json:"last_name"`
+	StoredPersonalBest RaceTime `json:"stored_personal_best,omitempty"`
+	PersonalBest       RaceTime `json:"personal_best,omitempty"`
+	StoredSeasonBest   RaceTime `json:"stored_season_best,omitempty"`
+	SeasonBest         RaceTime `json:"season_best,omitempty"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// Team scoring methods: the sum of the scorers' times, or of their places as
+// in cross country, lowest first either way.
+const (
+	ScoringTime   = "time"
+	ScoringPlaces = "places"
+)
+
+type Club struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Country is the ISO 3166-1 alpha-3 code of the club's country, if any.
+	Country     string        `json:"country,omitempty"`
+	Memberships []*Membership `json:"memberships,omitempty"`
+}
+
+// Membership is a runner's membership of a club from JoinedOn up to, but not
+// including, LeftOn, both "YYYY-MM-DD". It is open while LeftOn is empty.
+type Membership struct {
+	ID       string `json:"id"`
+	ClubID   string `json:"club_id"`
+	RunnerID string `json:"runner_id"`
+	JoinedOn string `json:"joined_on"`
+	LeftOn   string `json:"left_on,omitempty"`
+}
+
+// ClubBest is a member's best result over a distance while in the club.
+type ClubBest struct {
+	Rank   int     `json:"rank"`
+	Runner *Runner `json:"runner"`
+	Result *Result `json:"result"`
+}
+
+// TeamScore is the score of a club in a race, from its Scorers, its first
+// finishers. Time is set when scoring by time and Points by places.
+type TeamScore struct {
+	Rank    int       `json:"rank"`
+	ClubID  string    `json:"club_id"`
+	Club    string    `json:"club"`
+	Time    RaceTime  `json:"time,omitempty"`
+	Points  int       `json:"points,omitempty"`
+	Scorers []*Result `json:"scorers"`
+}
+
+// TeamResults rank the clubs with enough finishers in a race.
+type TeamResults struct {
+	Race     string       `json:"race"`
+	Year     int          `json:"year"`
+	Distance int          `json:"distance"`
+	Size     int          `json:"size"`
+	Scoring  string       `json:"scoring"`
+	Teams    []*TeamScore `json:"teams"`
+}
@@ -0,0 +1,48 @@
+package models
+
+// Comparison sets two runners against each other: their bests side by side,
+// the races both finished and their record in those races.
+type Comparison struct {
+	RunnerA *Runner           `json:"runner_a"`
+	RunnerB *Runner           `json:"runner_b"`
+	Bests   []*BestComparison `json:"bests"`
+	Races   []*HeadToHeadRace `json:"races"`
+	Record  *HeadToHeadRecord `json:"record"`
+}
+
+// Kinds of BestComparison.
+const (
+	BestPersonal = "personal_best"
+	BestSeason   = "season_best"
+)
+
+// BestComparison compares the personal or season bests of two runners. Ahead
+// is the ID of the faster runner, empty when they are equal or either has no
+// best, and Gap is the difference between the two times.
+type BestComparison struct {
+	Kind  string   `json:"kind"`
+	A     RaceTime `json:"a,omitempty"`
+	B     RaceTime `json:"b,omitempty"`
+	Ahead string   `json:"ahead,omitempty"`
+	Gap   RaceTime `json:"gap,omitempty"`
+}
+
+// HeadToHeadRace is a race both runners finished. Ahead is the ID of the
+// runner who placed ahead, by position when both have one and by time
+// otherwise, empty for a tie. Gap is the difference between their times.
+type HeadToHeadRace struct {
+	Location string   `json:"location"`
+	Year     int      `json:"year"`
+	Distance int      `json:"distance"`
+	ResultA  *Result  `json:"result_a"`
+	ResultB  *Result  `json:"result_b"`
+	Ahead    string   `json:"ahead,omitempty"`
+	Gap      RaceTime `json:"gap,omitempty"`
+}
+
+// HeadToHeadRecord counts the races each runner placed ahead in.
+type HeadToHeadRecord struct {
+	WinsA int `json:"wins_a"`
+	WinsB int `json:"wins_b"`
+	Ties  int `json:"ties"`
+}
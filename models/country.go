@@ -0,0 +1,10 @@
+package models
+
+// Country is an ISO 3166-1 country. Runners refer to countries by their
+// alpha-3 code.
+type Country struct {
+	Alpha2  string   `json:"alpha2"`
+	Alpha3  string   `json:"alpha3"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// ResultNote is an annotation of a result by an admin or a coach of the
+// runner. Author is empty once the author's user is deleted.
+type ResultNote struct {
+	ID        string `json:"id"`
+	ResultID  string `json:"result_id"`
+	Author    string `json:"author,omitempty"`
+	Note      string `json:"note"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// Prediction is a predicted race time over a distance in metres, based on the
+// source result with SourceResultID.
+type Prediction struct {
+	Distance       int      `json:"distance"`
+	Time           RaceTime `json:"time"`
+	SourceResultID string   `json:"source_result_id"`
+}
+
+// Predictions are the race times predicted for a runner from the results
+// they finished since Since.
+type Predictions struct {
+	RunnerID string `json:"runner_id"`
+	// Model is riegel or vdot. VDOT is the runner's VDOT with the vdot
+	// model.
+	Model       string        `json:"model"`
+	VDOT        float64       `json:"vdot,omitempty"`
+	Since       string        `json:"since"`
+	Sources     []*Result     `json:"sources"`
+	Predictions []*Prediction `json:"predictions"`
+}
+
+// TrainingPace is the time per unit, km or mile, a training zone is run at.
+// Zones run at a single pace have the same Slow and Fast pace.
+type TrainingPace struct {
+	Zone string   `json:"zone"`
+	Slow RaceTime `json:"slow"`
+	Fast RaceTime `json:"fast"`
+}
+
+// TrainingPaces are the training paces of a runner derived from Source, the
+// best result they finished since Since.
+type TrainingPaces struct {
+	RunnerID string          `json:"runner_id"`
+	VDOT     float64         `json:"vdot"`
+	Unit     string          `json:"unit"`
+	Since    string          `json:"since"`
+	Source   *Result         `json:"source"`
+	Paces    []*TrainingPace `json:"paces"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// Permissions the handlers require. Roles grant them; they are stored with
+// the roles in the database, where admins edit them.
+const (
+	PermissionRunnerRead     = "runner:read"
+	PermissionRunnerWrite    = "runner:write"
+	PermissionRunnerAny      = "runner:any"
+	PermissionResultWrite    = "result:write"
+	PermissionResultDelete   = "result:delete"
+	PermissionResultAnnotate = "result:annotate"
+	PermissionClubManage     = "club:manage"
+	PermissionCoachManage    = "coach:manage"
+	PermissionSeasonManage   = "season:manage"
+	PermissionUserManage     = "user:manage"
+)
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
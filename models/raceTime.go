@@ -0,0 +1,231 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRaceTime is returned for text that is not a race time.
+var ErrInvalidRaceTime = errors.New("invalid race time")
+
+// RaceTime is the elapsed time of a race. It parses clock times (h:mm:ss,
+// mm:ss, with optional fractional seconds), ISO-8601 durations and Postgres
+// interval text, and always formats as HH:MM:SS, followed by the fraction of
+// a second when there is one. The zero RaceTime means no time and is stored
+// as NULL.
+type RaceTime time.Duration
+
+var (
+	isoDuration = regexp.MustCompile(
+		`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+	intervalDays = regexp.MustCompile(`^(\d+) days?(?: (.+))?$`)
+)
+
+// ParseRaceTime parses text in any of the formats RaceTime accepts, e.g.
+// "2:05:30", "59:12", "01:02:03.45", "PT2H5M30S" or "1 day 02:00:00".
+func ParseRaceTime(text string) (RaceTime, error) {
+	text = strings.TrimSpace(text)
+	var duration time.Duration
+	var err error
+	if strings.HasPrefix(text, "P") {
+		duration, err = parseISODuration(text)
+	} else {
+		duration, err = parseInterval(text)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidRaceTime, text)
+	}
+	return RaceTime(duration), nil
+}
+
+func parseISODuration(text string) (time.Duration, error) {
+	match := isoDuration.FindStringSubmatch(text)
+	if match == nil || text == "P" || strings.HasSuffix(text, "T") {
+		return 0, ErrInvalidRaceTime
+	}
+	var duration time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+		if match[i+1] == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(match[i+1], 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(value) * unit
+	}
+	if match[4] != "" {
+		seconds, err := parseSeconds(strings.Replace(match[4], ",", ".", 1))
+		if err != nil {
+			return 0, err
+		}
+		duration += seconds
+	}
+	return duration, nil
+}
+
+// parseInterval parses clock times, optionally preceded by a number of days
+// as Postgres writes intervals of a day or more.
+func parseInterval(text string) (time.Duration, error) {
+	var days time.Duration
+	if match := intervalDays.FindStringSubmatch(text); match != nil {
+		value, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		days = time.Duration(value) * 24 * time.Hour
+		if match[2] == "" {
+			return days, nil
+		}
+		text = match[2]
+		if strings.Count(text, ":") != 2 {
+			return 0, ErrInvalidRaceTime
+		}
+	}
+	fields := strings.Split(text, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, ErrInvalidRaceTime
+	}
+	last := fields[len(fields)-1]
+	seconds, err := parseSeconds(last)
+	if whole, _, _ := strings.Cut(last, "."); err != nil || len(whole) != 2 ||
+		seconds >= time.Minute {
+		return 0, ErrInvalidRaceTime
+	}
+	duration := days + seconds
+	units := []time.Duration{time.Minute, time.Hour}
+	for i := len(fields) - 2; i >= 0; i-- {
+		field := fields[i]
+		leading := i == 0
+		if field == "" || !isDigits(field) || !leading && len(field) != 2 {
+			return 0, ErrInvalidRaceTime
+		}
+		value, err := strconv.ParseInt(field, 10, 32)
+		if err != nil || !leading && value >= 60 {
+			return 0, ErrInvalidRaceTime
+		}
+		duration += time.Duration(value) * units[len(fields)-2-i]
+	}
+	return duration, nil
+}
+
+// parseSeconds parses whole seconds with an optional fraction of up to nine
+// digits.
+func parseSeconds(text string) (time.Duration, error) {
+	whole, fraction, hasFraction := strings.Cut(text, ".")
+	if whole == "" || !isDigits(whole) ||
+		hasFraction && (fraction == "" || len(fraction) > 9 || !isDigits(fraction)) {
+		return 0, ErrInvalidRaceTime
+	}
+	seconds, err := strconv.ParseInt(whole, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	duration := time.Duration(seconds) * time.Second
+	if hasFraction {
+		nanoseconds, _ := strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		duration += time.Duration(nanoseconds)
+	}
+	return duration, nil
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Duration returns the race time as a time.Duration.
+func (rt RaceTime) Duration() time.Duration {
+	return time.Duration(rt)
+}
+
+// Precision returns the number of digits of a second needed to write the
+// race time exactly.
+func (rt RaceTime) Precision() int {
+	fraction := time.Duration(rt) % time.Second
+	if fraction == 0 {
+		return 0
+	}
+	return len(strings.TrimRight(fmt.Sprintf("%09d", fraction), "0"))
+}
+
+// String formats the race time canonically, e.g. "02:05:30" or "00:10:02.45".
+func (rt RaceTime) String() string {
+	duration := time.Duration(rt)
+	hours := duration / time.Hour
+	duration -= hours * time.Hour
+	minutes := duration / time.Minute
+	duration -= minutes * time.Minute
+	seconds := duration / time.Second
+	duration -= seconds * time.Second
+	text := fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	if duration > 0 {
+		text += "." + strings.TrimRight(fmt.Sprintf("%09d", duration), "0")
+	}
+	return text
+}
+
+func (rt RaceTime) MarshalJSON() ([]byte, error) {
+	if rt == 0 {
+		return []byte("null"), nil
+	}
+	return json.Marshal(rt.String())
+}
+
+func (rt *RaceTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*rt = 0
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("%w %s", ErrInvalidRaceTime, data)
+	}
+	parsed, err := ParseRaceTime(text)
+	if err != nil {
+		return err
+	}
+	*rt = parsed
+	return nil
+}
+
+// Scan reads a Postgres interval.
+func (rt *RaceTime) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*rt = 0
+		return nil
+	case []byte:
+		return rt.scanText(string(src))
+	case string:
+		return rt.scanText(src)
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidRaceTime, src)
+}
+
+func (rt *RaceTime) scanText(text string) error {
+	parsed, err := ParseRaceTime(text)
+	if err != nil {
+		return err
+	}
+	*rt = parsed
+	return nil
+}
+
+// Value writes the race time as interval text, or NULL when it is zero.
+func (rt RaceTime) Value() (driver.Value, error) {
+	if rt == 0 {
+		return nil, nil
+	}
+	return rt.String(), nil
+}
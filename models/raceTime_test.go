@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestParseRaceTime(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"02:13:13", 2*time.Hour + 13*time.Minute + 13*time.Second},
+		{"2:05:30", 2*time.Hour + 5*time.Minute + 30*time.Second},
+		{"59:12", 59*time.Minute + 12*time.Second},
+		{"4:02", 4*time.Minute + 2*time.Second},
+		{"75:00", 75 * time.Minute},
+		{"01:02:03.45", time.Hour + 2*time.Minute + 3*time.Second + 450*time.Millisecond},
+		{"00:00:09.58", 9*time.Second + 580*time.Millisecond},
+		{"00:00:01.123456789", time.Second + 123456789},
+		{" 02:00:00 ", 2 * time.Hour},
+		{"PT2H5M30S", 2*time.Hour + 5*time.Minute + 30*time.Second},
+		{"PT9.58S", 9*time.Second + 580*time.Millisecond},
+		{"PT59M12,5S", 59*time.Minute + 12*time.Second + 500*time.Millisecond},
+		{"P1DT2H", 26 * time.Hour},
+		{"1 day 02:00:00", 26 * time.Hour},
+		{"2 days", 48 * time.Hour},
+		{"26:00:00", 26 * time.Hour},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			raceTime, err := ParseRaceTime(test.text)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, test.want, raceTime.Duration())
+		})
+	}
+}
+
+func TestParseRaceTimeErrors(t *testing.T) {
+	for _, text := range []string{
+		"", "12", "2:5:30", "02:60:00", "02:00:60", "02:00:5", "1:02:03:04",
+		"-01:00:00", "02:00:00.", "02:00:00.1234567890", "abc", "02h05m",
+		"P", "PT", "PT2H5M30", "1 day 02:00", "02:00:00 day",
+	} {
+		t.Run(text, func(t *testing.T) {
+			_, err := ParseRaceTime(text)
+			assert.Equal(t, true, err != nil)
+		})
+	}
+}
+
+func TestRaceTimeString(t *testing.T) {
+	tests := []struct {
+		raceTime RaceTime
+		want     string
+	}{
+		{0, "00:00:00"},
+		{RaceTime(2*time.Hour + 5*time.Minute + 30*time.Second), "02:05:30"},
+		{RaceTime(9*time.Second + 580*time.Millisecond), "00:00:09.58"},
+		{RaceTime(26 * time.Hour), "26:00:00"},
+		{RaceTime(time.Second + 1), "00:00:01.000000001"},
+	}
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, test.raceTime.String())
+		})
+	}
+}
+
+func TestRaceTimePrecision(t *testing.T) {
+	assert.Equal(t, 0, RaceTime(2*time.Hour).Precision())
+	assert.Equal(t, 1, RaceTime(time.Second+500*time.Millisecond).Precision())
+	assert.Equal(t, 2, RaceTime(9*time.Second+580*time.Millisecond).Precision())
+	assert.Equal(t, 9, RaceTime(time.Second+1).Precision())
+}
+
+func TestRaceTimeJSON(t *testing.T) {
+	var result Result
+	err := json.Unmarshal([]byte(`{"race_result": "2:05:30.5"}`), &result)
+	assert.Equal(t, nil, err)
+	data, _ := json.Marshal(result.RaceResult)
+	assert.Equal(t, `"02:05:30.5"`, string(data))
+	err = json.Unmarshal([]byte(`{"race_result": 7530}`), &result)
+	assert.Equal(t, true, err != nil)
+	data, _ = json.Marshal(Runner{ID: "1"})
+	assert.Equal(t, `{"id":"1","first_name":"","last_name":"","is_active":false,"country":""}`,
+		string(data))
+}
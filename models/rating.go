@@ -0,0 +1,29 @@
+package models
+
+// Rating is the rating of a runner over a distance after a race. Race is the
+// location of the race in lower case and RaceDate the date it counts on.
+type Rating struct {
+	RunnerID string  `json:"runner_id"`
+	Distance int     `json:"distance"`
+	Race     string  `json:"race"`
+	RaceDate string  `json:"race_date"`
+	Rating   float64 `json:"rating"`
+	// Races is the number of races the rating is based on.
+	Races int `json:"races"`
+}
+
+// Ranking is a place in the rankings.
+type Ranking struct {
+	Rank     int     `json:"rank"`
+	Runner   *Runner `json:"runner"`
+	Rating   float64 `json:"rating"`
+	Races    int     `json:"races"`
+	LastRace string  `json:"last_race"`
+}
+
+// Rankings rank the runners by their rating over a distance as of a date.
+type Rankings struct {
+	Distance int        `json:"distance"`
+	Date     string     `json:"date"`
+	Rankings []*Ranking `json:"rankings"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// Scopes of records.
+const (
+	ScopeWorld    = "world"
+	ScopeNational = "national"
+)
+
+// Record is the best mark over a distance in a country, or in the world when
+// Country is empty, and in a category, or in all categories when Category is
+// empty.
+type Record struct {
+	ID         string   `json:"id"`
+	Country    string   `json:"country,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	Distance   int      `json:"distance"`
+	Time       RaceTime `json:"time"`
+	ResultID   string   `json:"result_id"`
+	RunnerID   string   `json:"runner_id"`
+	RunnerName string   `json:"runner_name"`
+	Location   string   `json:"location"`
+	RaceDate   string   `json:"race_date"`
+	Current    bool     `json:"current"`
+	PreviousID string   `json:"-"`
+	// History is the lineage of a current record, the records it broke
+	// from the latest back.
+	History []*Record `json:"history,omitempty"`
+}
@@ -1,6 +1,7 @@
 package models
 
 type ResponseError struct {
-	Message string `json:"message"`
-	Status  int    `json:"-"`
+	Message   string `json:"message"`
+	Status    int    `json:"-"`
+	RequestID string `json:"request_id,omitempty"`
 }
@@ -1,10 +1,43 @@
 package models
 
+// Result statuses. Only finished results have a place and count towards
+// personal and season bests and leaderboards.
+const (
+	StatusFinished     = "FIN"
+	StatusDidNotFinish = "DNF"
+	StatusDidNotStart  = "DNS"
+	StatusDisqualified = "DQ"
+)
+
+// MarathonDistance is the distance of results, in metres, when none is given.
+// Personal and season bests and leaderboards only count marathons.
+const MarathonDistance = 42195
+
 type Result struct {
-	ID         string `json:"id"`
-	RunnerID   string `json:"runner_id"`
-	RaceResult string `json:"race_result"`
-	Location   string `json:"location"`
-	Position   int    `json:"position,omitempty"`
-	Year       int    `json:"year"`
+	ID         string   `json:"id"`
+	RunnerID   string   `json:"runner_id"`
+	RaceResult RaceTime `json:"race_result,omitempty"`
+	Location   string   `json:"location"`
+	Position   int      `json:"position,omitempty"`
+	Year       int      `json:"year"`
+	RaceDate   string   `json:"race_date,omitempty"`
+	GunTime    RaceTime `json:"gun_time,omitempty"`
+	ChipTime   RaceTime `json:"chip_time,omitempty"`
+	// Precision is the number of digits of a second the times are recorded
+	// with, 0 for whole seconds and 2 for hundredths.
+	Precision    int    `json:"precision,omitempty"`
+	Status       string `json:"status"`
+	StatusReason string `json:"status_reason,omitempty"`
+	// Distance is in metres.
+	Distance int `json:"distance"`
+	// AgeGrade is the age-graded performance in percent, when the runner's
+	// category and date of birth are known and the age grading table covers
+	// the category. With the bundled table it is approximate, runners under 30
+	// are graded as open class and category X runners have none.
+	AgeGrade float64 `json:"age_grade,omitempty"`
+	// ClubID and Club are the club the runner belonged to on the race date.
+	ClubID string `json:"club_id,omitempty"`
+	Club   string `json:"club,omitempty"`
+	// Records are the records the result set when it was created.
+	Records []*Record `json:"records,omitempty"`
 }
@@ -1,13 +1,18 @@
 package models
 
 type Runner struct {
-	ID           string    `json:"id"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	Age          int       `json:"age,omitempty"`
-	IsActive     bool      `json:"is_active"`
-	Country      string    `json:"country"`
-	PersonalBest string    `json:"personal_best,omitempty"`
-	SeasonBest   string    `json:"season_best,omitempty"`
-	Results      []*Result `json:"results,omitempty"`
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Age       int    `json:"age,omitempty"`
+	IsActive  bool   `json:"is_active"`
+	// Country is the ISO 3166-1 alpha-3 code of the runner's country.
+	Country      string   `json:"country"`
+	PersonalBest RaceTime `json:"personal_best,omitempty"`
+	SeasonBest   RaceTime `json:"season_best,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	DateOfBirth  string   `json:"date_of_birth,omitempty"`
+	// AgeGroup is set on leaderboards, as of the date of the ranked result.
+	AgeGroup string    `json:"age_group,omitempty"`
+	Results  []*Result `json:"results,omitempty"`
 }
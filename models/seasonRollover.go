@@ -0,0 +1,9 @@
+package models
+
+// SeasonRollover reports a reset of season bests to the season from
+// SeasonStart (inclusive) to SeasonEnd (exclusive).
+type SeasonRollover struct {
+	SeasonStart    string `json:"season_start"`
+	SeasonEnd      string `json:"season_end"`
+	RunnersUpdated int64  `json:"runners_updated"`
+}
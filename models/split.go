@@ -0,0 +1,31 @@
+package models
+
+// Split is the elapsed time of a result at a distance marker, in metres from
+// the start.
+type Split struct {
+	ID          string   `json:"id"`
+	ResultID    string   `json:"result_id"`
+	Distance    int      `json:"distance"`
+	ElapsedTime RaceTime `json:"elapsed_time"`
+	// SegmentTime and Pace describe the segment since the previous split, or
+	// since the start for the first one. Pace is the time per kilometre.
+	SegmentTime RaceTime `json:"segment_time,omitempty"`
+	Pace        RaceTime `json:"pace,omitempty"`
+}
+
+// Pacing values of SplitAnalysis.
+const (
+	PacingNegative = "negative"
+	PacingPositive = "positive"
+	PacingEven     = "even"
+)
+
+// SplitAnalysis is the splits of a result with their segment paces.
+type SplitAnalysis struct {
+	ResultID string   `json:"result_id"`
+	Splits   []*Split `json:"splits"`
+	// Pacing compares the second half of the distance covered by the splits
+	// with the first half. It is negative when the second half was faster,
+	// and empty with fewer than two splits.
+	Pacing string `json:"pacing,omitempty"`
+}
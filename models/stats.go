@@ -0,0 +1,50 @@
+package models
+
+// RunnerStats summarizes the results of a runner.
+type RunnerStats struct {
+	RunnerID string `json:"runner_id"`
+	Races    int    `json:"races"`
+	// StatusCounts counts the results by status.
+	StatusCounts map[string]int `json:"status_counts"`
+	// AveragePosition is over the finished results with a position.
+	AveragePosition float64          `json:"average_position,omitempty"`
+	Years           []*YearStats     `json:"years"`
+	Distances       []*DistanceStats `json:"distances"`
+}
+
+// YearStats counts the races of a runner in a year.
+type YearStats struct {
+	Year     int `json:"year"`
+	Races    int `json:"races"`
+	Finished int `json:"finished"`
+}
+
+// DistanceStats summarizes the finished results of a runner over a distance
+// in metres.
+type DistanceStats struct {
+	Distance    int                 `json:"distance"`
+	Races       int                 `json:"races"`
+	Best        RaceTime            `json:"best"`
+	YearlyBests []*YearlyBest       `json:"yearly_bests"`
+	Progression []*ProgressionPoint `json:"progression"`
+}
+
+// YearlyBest is the best result of a year over a distance. Improvement is the
+// percentage it is faster than the best of the previous year the runner
+// raced the distance, negative when it is slower, and is missing for the
+// first year.
+type YearlyBest struct {
+	Year        int      `json:"year"`
+	Time        RaceTime `json:"time"`
+	ResultID    string   `json:"result_id"`
+	Improvement *float64 `json:"improvement,omitempty"`
+}
+
+// ProgressionPoint is a finished result in the order of race dates, with the
+// best time over the distance up to it.
+type ProgressionPoint struct {
+	Date     string   `json:"date"`
+	ResultID string   `json:"result_id"`
+	Time     RaceTime `json:"time"`
+	Best     RaceTime `json:"best"`
+}
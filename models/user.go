@@ -1,9 +1,27 @@
 package models
 
+import "slices"
+
+// Built-in roles. Admins can grant them other permissions and add roles.
+// Users whose role lacks PermissionRunnerAny, such as coaches, act only for
+// the runners assigned to them.
+const (
+	RoleAdmin  = "admin"
+	RoleRunner = "runner"
+	RoleCoach  = "coach"
+)
+
 type User struct {
 	ID          string `json:"id"`
 	Username    string `json:"username"`
 	Password    string `json:"user_password"`
 	Role        string `json:"user_role"`
 	AccessToken string `json:"access_token"`
+	// Permissions are the permissions the role grants.
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// HasPermission reports whether the user's role grants permission.
+func (u *User) HasPermission(permission string) bool {
+	return slices.Contains(u.Permissions, permission)
 }
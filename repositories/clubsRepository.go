@@ -0,0 +1,402 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"net/http"
+	"time"
+)
+
+// Conflicts the clubs_name and club_memberships_overlap constraints report,
+// worded like the checks of ClubsService.
+const (
+	clubExists         = "Club already exists"
+	membershipOverlaps = "Membership overlaps another membership"
+)
+
+// memberOnRaceDate joins a result with the club membership of its runner on
+// the race date.
+const memberOnRaceDate = `club_memberships.runner_id = results.runner_id
+	AND ` + raceDate + ` >= club_memberships.joined_on
+	AND (club_memberships.left_on IS NULL
+	     OR ` + raceDate + ` < club_memberships.left_on)`
+
+type ClubsRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewClubsRepository(dbHandler *sql.DB) *ClubsRepository {
+	return &ClubsRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+func (cr ClubsRepository) CreateClub(ctx context.Context,
+	club *models.Club) (*models.Club, *models.ResponseError) {
+	query := `
+		INSERT INTO clubs(name, country)
+		VALUES ($1, $2)
+		RETURNING id
+    `
+	var clubID string
+	err := cr.dbHandler.QueryRowContext(ctx, query, club.Name,
+		sql.NullString{String: club.Country, Valid: club.Country != ""}).Scan(&clubID)
+	if err != nil {
+		return nil, conflictError(err, clubExists)
+	}
+	return &models.Club{
+		ID:      clubID,
+		Name:    club.Name,
+		Country: club.Country,
+	}, nil
+}
+
+func (cr ClubsRepository) UpdateClub(ctx context.Context,
+	club *models.Club) *models.ResponseError {
+	query := `
+		UPDATE clubs
+		SET
+		    name = $1,
+		    country = $2
+		WHERE id = $3
+    `
+	res, err := cr.dbHandler.ExecContext(ctx, query, club.Name,
+		sql.NullString{String: club.Country, Valid: club.Country != ""}, club.ID)
+	if err != nil {
+		return conflictError(err, clubExists)
+	}
+	return affectedOne(res, err, "Club not found")
+}
+
+// DeleteClub deletes a club with its memberships. Results of its former
+// members are no longer attributed to it.
+func (cr ClubsRepository) DeleteClub(ctx context.Context,
+	clubID string) *models.ResponseError {
+	query := `
+		DELETE FROM clubs
+		WHERE id = $1
+    `
+	res, err := cr.dbHandler.ExecContext(ctx, query, clubID)
+	return affectedOne(res, err, "Club not found")
+}
+
+// GetClub returns the club with clubID, or nil when there is none.
+func (cr ClubsRepository) GetClub(ctx context.Context,
+	clubID string) (*models.Club, *models.ResponseError) {
+	clubs, responseErr := cr.getClubs(ctx, "WHERE id = $1", clubID)
+	if responseErr != nil || len(clubs) == 0 {
+		return nil, responseErr
+	}
+	return clubs[0], nil
+}
+
+// GetClubs returns every club, ordered by name.
+func (cr ClubsRepository) GetClubs(ctx context.Context) ([]*models.Club, *models.ResponseError) {
+	return cr.getClubs(ctx, "")
+}
+
+func (cr ClubsRepository) getClubs(ctx context.Context, where string,
+	args ...any) ([]*models.Club, *models.ResponseError) {
+	query := `
+		SELECT id, name, country
+		FROM clubs
+		` + where + `
+		ORDER BY name
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	clubs := make([]*models.Club, 0)
+	for rows.Next() {
+		club := &models.Club{}
+		var country sql.NullString
+		err = rows.Scan(&club.ID, &club.Name, &country)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		club.Country = country.String
+		clubs = append(clubs, club)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return clubs, nil
+}
+
+func (cr ClubsRepository) CreateMembership(ctx context.Context,
+	membership *models.Membership) (*models.Membership, *models.ResponseError) {
+	query := `
+		INSERT INTO club_memberships(club_id, runner_id, joined_on, left_on)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+    `
+	var membershipID string
+	err := cr.dbHandler.QueryRowContext(ctx, query, membership.ClubID,
+		membership.RunnerID, membership.JoinedOn,
+		sql.NullString{String: membership.LeftOn, Valid: membership.LeftOn != ""}).
+		Scan(&membershipID)
+	if err != nil {
+		return nil, conflictError(err, membershipOverlaps)
+	}
+	return &models.Membership{
+		ID:       membershipID,
+		ClubID:   membership.ClubID,
+		RunnerID: membership.RunnerID,
+		JoinedOn: membership.JoinedOn,
+		LeftOn:   membership.LeftOn,
+	}, nil
+}
+
+// UpdateMembership updates the dates of a membership.
+func (cr ClubsRepository) UpdateMembership(ctx context.Context,
+	membership *models.Membership) *models.ResponseError {
+	query := `
+		UPDATE club_memberships
+		SET
+		    joined_on = $1,
+		    left_on = $2
+		WHERE id = $3
+    `
+	res, err := cr.dbHandler.ExecContext(ctx, query, membership.JoinedOn,
+		sql.NullString{String: membership.LeftOn, Valid: membership.LeftOn != ""},
+		membership.ID)
+	if err != nil {
+		return conflictError(err, membershipOverlaps)
+	}
+	return affectedOne(res, err, "Membership not found")
+}
+
+func (cr ClubsRepository) DeleteMembership(ctx context.Context,
+	membershipID string) *models.ResponseError {
+	query := `
+		DELETE FROM club_memberships
+		WHERE id = $1
+    `
+	res, err := cr.dbHandler.ExecContext(ctx, query, membershipID)
+	return affectedOne(res, err, "Membership not found")
+}
+
+// GetMembership returns the membership with membershipID, or nil when there
+// is none.
+func (cr ClubsRepository) GetMembership(ctx context.Context,
+	membershipID string) (*models.Membership, *models.ResponseError) {
+	memberships, responseErr := cr.getMemberships(ctx, "id", membershipID)
+	if responseErr != nil || len(memberships) == 0 {
+		return nil, responseErr
+	}
+	return memberships[0], nil
+}
+
+// GetClubMemberships returns the memberships of a club, past and present,
+// in the order the runners joined.
+func (cr ClubsRepository) GetClubMemberships(ctx context.Context,
+	clubID string) ([]*models.Membership, *models.ResponseError) {
+	return cr.getMemberships(ctx, "club_id", clubID)
+}
+
+// GetRunnerMemberships returns the club memberships of a runner over their
+// career, in the order they joined.
+func (cr ClubsRepository) GetRunnerMemberships(ctx context.Context,
+	runnerID string) ([]*models.Membership, *models.ResponseError) {
+	return cr.getMemberships(ctx, "runner_id", runnerID)
+}
+
+func (cr ClubsRepository) getMemberships(ctx context.Context, column,
+	value string) ([]*models.Membership, *models.ResponseError) {
+	query := `
+		SELECT id, club_id, runner_id, joined_on, left_on
+		FROM club_memberships
+		WHERE ` + column + ` = $1
+		ORDER BY joined_on
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, value)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	memberships := make([]*models.Membership, 0)
+	for rows.Next() {
+		membership := &models.Membership{}
+		var joinedOn time.Time
+		var leftOn sql.NullTime
+		err = rows.Scan(&membership.ID, &membership.ClubID,
+			&membership.RunnerID, &joinedOn, &leftOn)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		membership.JoinedOn = joinedOn.Format("2006-01-02")
+		membership.LeftOn = formatDate(leftOn)
+		memberships = append(memberships, membership)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return memberships, nil
+}
+
+// GetClubBests returns the best finished result over distance of every
+// runner set while a member of the club, in year or in any year when year is
+// 0, fastest first.
+func (cr ClubsRepository) GetClubBests(ctx context.Context, clubID string,
+	distance, year int) ([]*models.ClubBest, *models.ResponseError) {
+	query := `
+		SELECT ` + runnerColumns + `, best.id, best.race_result,
+		       best.location, best.year, best.race_date
+		FROM (
+		    SELECT DISTINCT ON (results.runner_id) results.runner_id,
+		           results.id, results.race_result, results.location,
+		           results.year, results.race_date
+		    FROM results
+		    JOIN club_memberships ON ` + memberOnRaceDate + `
+		    WHERE club_memberships.club_id = $1
+		      AND results.distance = $2 AND results.` + finished + `
+		      AND ($3 = 0 OR results.year = $3)
+		    ORDER BY results.runner_id, results.race_result) best
+		JOIN runners ON runners.id = best.runner_id
+		ORDER BY best.race_result
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, clubID, distance, year)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	bests := make([]*models.ClubBest, 0)
+	for rows.Next() {
+		result := &models.Result{
+			ClubID:   clubID,
+			Distance: distance,
+			Status:   models.StatusFinished,
+		}
+		var date sql.NullTime
+		runner, err := scanRunner(rows, &result.ID, &result.RaceResult,
+			&result.Location, &result.Year, &date)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		result.RunnerID = runner.ID
+		result.RaceDate = formatDate(date)
+		bests = append(bests, &models.ClubBest{
+			Rank:   len(bests) + 1,
+			Runner: runner,
+			Result: result,
+		})
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return bests, nil
+}
+
+// GetRaceResults returns the finished results of a race, given by its
+// location in any case, in year over distance, with the club each runner
+// belonged to on the race date.
+func (cr ClubsRepository) GetRaceResults(ctx context.Context, race string,
+	year, distance int) ([]*models.Result, *models.ResponseError) {
+	query := `
+		SELECT results.id, results.runner_id, results.race_result,
+		       results.position, results.location, results.race_date,
+		       clubs.id, clubs.name
+		FROM results
+		LEFT JOIN club_memberships ON ` + memberOnRaceDate + `
+		LEFT JOIN clubs ON clubs.id = club_memberships.club_id
+		WHERE ` + raceKey + ` = lower(trim($1))
+		  AND results.year = $2 AND results.distance = $3
+		  AND results.` + finished + `
+		ORDER BY results.race_result
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, race, year, distance)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	results := make([]*models.Result, 0)
+	for rows.Next() {
+		result := &models.Result{
+			Year:     year,
+			Distance: distance,
+			Status:   models.StatusFinished,
+		}
+		var position sql.NullInt64
+		var date sql.NullTime
+		var clubID, club sql.NullString
+		err = rows.Scan(&result.ID, &result.RunnerID, &result.RaceResult,
+			&position, &result.Location, &date, &clubID, &club)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		result.Position = int(position.Int64)
+		result.RaceDate = formatDate(date)
+		result.ClubID = clubID.String
+		result.Club = club.String
+		results = append(results, result)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return results, nil
+}
+
+// affectedOne converts the outcome of a statement changing a single row
+// into a response error, a not found error with message when no row
+// changed.
+func affectedOne(res sql.Result, err error, message string) *models.ResponseError {
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	if rowsAffected == 0 {
+		return &models.ResponseError{
+			Message: message,
+			Status:  http.StatusNotFound,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"net/http"
+)
+
+type CoachesRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewCoachesRepository(dbHandler *sql.DB) *CoachesRepository {
+	return &CoachesRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+// AssignAthlete assigns a runner to a coach; assigning them again changes
+// nothing.
+func (cr CoachesRepository) AssignAthlete(ctx context.Context, coachID,
+	runnerID string) *models.ResponseError {
+	query := `
+		INSERT INTO coach_athletes(coach_id, runner_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+    `
+	_, err := cr.dbHandler.ExecContext(ctx, query, coachID, runnerID)
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
+
+func (cr CoachesRepository) UnassignAthlete(ctx context.Context, coachID,
+	runnerID string) *models.ResponseError {
+	query := `
+		DELETE FROM coach_athletes
+		WHERE coach_id = $1 AND runner_id = $2
+    `
+	res, err := cr.dbHandler.ExecContext(ctx, query, coachID, runnerID)
+	return affectedOne(res, err, "Athlete not assigned")
+}
+
+// IsAssigned reports whether a runner is assigned to a coach.
+func (cr CoachesRepository) IsAssigned(ctx context.Context, coachID,
+	runnerID string) (bool, *models.ResponseError) {
+	query := `
+		SELECT EXISTS (
+		    SELECT 1
+		    FROM coach_athletes
+		    WHERE coach_id = $1 AND runner_id = $2)
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, coachID, runnerID)
+	if err != nil {
+		return false, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	var assigned bool
+	for rows.Next() {
+		err = rows.Scan(&assigned)
+		if err != nil {
+			return false, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return false, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return assigned, nil
+}
+
+// GetAthletes returns the runners assigned to a coach, by last name.
+func (cr CoachesRepository) GetAthletes(ctx context.Context,
+	coachID string) ([]*models.Runner, *models.ResponseError) {
+	query := `
+		SELECT ` + runnerColumns + `
+		FROM runners
+		JOIN coach_athletes ON coach_athletes.runner_id = runners.id
+		WHERE coach_athletes.coach_id = $1
+		ORDER BY runners.last_name, runners.first_name
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, coachID)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	runners := make([]*models.Runner, 0)
+	for rows.Next() {
+		runner, err := scanRunner(rows)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		runners = append(runners, runner)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return runners, nil
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+type CountriesRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewCountriesRepository(dbHandler *sql.DB) *CountriesRepository {
+	return &CountriesRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+// GetCountries returns every country with its aliases, ordered by name.
+func (cr CountriesRepository) GetCountries(ctx context.Context) ([]*models.Country, *models.ResponseError) {
+	query := `
+		SELECT countries.alpha2, countries.alpha3, countries.name,
+		       COALESCE(array_agg(country_aliases.alias ORDER BY country_aliases.alias)
+		           FILTER (WHERE country_aliases.alias IS NOT NULL), '{}')
+		FROM countries
+		LEFT JOIN country_aliases ON country_aliases.alpha3 = countries.alpha3
+		GROUP BY countries.alpha3
+		ORDER BY countries.name
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	countries := make([]*models.Country, 0)
+	for rows.Next() {
+		country := &models.Country{}
+		err = rows.Scan(&country.Alpha2, &country.Alpha3, &country.Name,
+			pq.Array(&country.Aliases))
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		countries = append(countries, country)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return countries, nil
+}
+
+// FindCountry returns the alpha-3 code of the country named name, which is
+// an alpha-2 or alpha-3 code, a name or an alias in any case, or an empty
+// string when no country has that name.
+func (cr CountriesRepository) FindCountry(ctx context.Context,
+	name string) (string, *models.ResponseError) {
+	query := `
+		SELECT alpha3
+		FROM country_names
+		WHERE name = lower(trim($1))
+    `
+	rows, err := cr.dbHandler.QueryContext(ctx, query, name)
+	if err != nil {
+		return "", &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	var alpha3 string
+	for rows.Next() {
+		err = rows.Scan(&alpha3)
+		if err != nil {
+			return "", &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return "", &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return alpha3, nil
+}
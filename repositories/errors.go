@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+// Postgres error codes of constraint violations.
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	exclusionViolation  = "23P01"
+)
+
+// violates reports whether err is a violation of a constraint with code.
+func violates(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// conflictError reports a violation of a unique or exclusion constraint, as
+// when a concurrent request got in between a service's check and the write,
+// as a conflict with message and any other error as a server error.
+func conflictError(err error, message string) *models.ResponseError {
+	if violates(err, uniqueViolation) || violates(err, exclusionViolation) {
+		return &models.ResponseError{
+			Message: message,
+			Status:  http.StatusConflict,
+		}
+	}
+	return &models.ResponseError{
+		Message: err.Error(),
+		Status:  http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+// raceKey identifies the race of a result together with its year and
+// distance: results of the same race share the location, ignoring case and
+// surrounding spaces.
+const raceKey = "lower(trim(location))"
+
+type RatingsRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewRatingsRepository(dbHandler *sql.DB) *RatingsRepository {
+	return &RatingsRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+// GetRaceResults returns the finished results over distance of the races
+// counting on from or later, ordered by race date. The location of the
+// results is their race key and their race date the earliest date of the
+// race.
+func (rr RatingsRepository) GetRaceResults(ctx context.Context, distance int,
+	from string) ([]*models.Result, *models.ResponseError) {
+	query := `
+		WITH races AS (
+		    SELECT ` + raceKey + ` AS race, year, MIN(` + raceDate + `) AS race_date
+		    FROM results
+		    WHERE distance = $1 AND ` + finished + `
+		    GROUP BY ` + raceKey + `, year)
+		SELECT results.id, results.runner_id, results.race_result,
+		       results.position, races.race, races.race_date, results.year
+		FROM results
+		JOIN races ON races.race = ` + raceKey + ` AND races.year = results.year
+		WHERE results.distance = $1 AND ` + finished + `
+		  AND races.race_date >= $2
+		ORDER BY races.race_date, races.race, results.year
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, distance, from)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	results := make([]*models.Result, 0)
+	for rows.Next() {
+		result := &models.Result{Distance: distance, Status: models.StatusFinished}
+		var position sql.NullInt64
+		var date time.Time
+		err = rows.Scan(&result.ID, &result.RunnerID, &result.RaceResult,
+			&position, &result.Location, &date, &result.Year)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		result.Position = int(position.Int64)
+		result.RaceDate = date.Format("2006-01-02")
+		results = append(results, result)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return results, nil
+}
+
+// GetRatingsBefore returns the latest rating over distance of every runner
+// who raced it before the date before, by runner ID.
+func (rr RatingsRepository) GetRatingsBefore(ctx context.Context, distance int,
+	before string) (map[string]*models.Rating, *models.ResponseError) {
+	query := `
+		SELECT DISTINCT ON (runner_id) runner_id, race, race_date, rating, races
+		FROM ratings
+		WHERE distance = $1 AND race_date < $2
+		ORDER BY runner_id, race_date DESC, races DESC
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, distance, before)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	ratings := map[string]*models.Rating{}
+	for rows.Next() {
+		rating := &models.Rating{Distance: distance}
+		var date time.Time
+		err = rows.Scan(&rating.RunnerID, &rating.Race, &date, &rating.Rating,
+			&rating.Races)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		rating.RaceDate = date.Format("2006-01-02")
+		ratings[rating.RunnerID] = rating
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return ratings, nil
+}
+
+// ReplaceRatings replaces the ratings over distance of the races counting on
+// from or later with ratings, in a transaction of its own.
+func (rr RatingsRepository) ReplaceRatings(ctx context.Context, distance int,
+	from string, ratings []*models.Rating) *models.ResponseError {
+	transaction, err := rr.dbHandler.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	_, err = transaction.ExecContext(ctx, `
+		DELETE FROM ratings
+		WHERE distance = $1 AND race_date >= $2
+    `, distance, from)
+	if err != nil {
+		transaction.Rollback()
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	runnerIDs := make([]string, 0, len(ratings))
+	races := make([]string, 0, len(ratings))
+	raceDates := make([]string, 0, len(ratings))
+	values := make([]float64, 0, len(ratings))
+	counts := make([]int64, 0, len(ratings))
+	for _, rating := range ratings {
+		runnerIDs = append(runnerIDs, rating.RunnerID)
+		races = append(races, rating.Race)
+		raceDates = append(raceDates, rating.RaceDate)
+		values = append(values, rating.Rating)
+		counts = append(counts, int64(rating.Races))
+	}
+	_, err = transaction.ExecContext(ctx, `
+		INSERT INTO ratings(runner_id, distance, race, race_date, rating, races)
+		SELECT rating.runner_id, $1, rating.race, rating.race_date,
+		       rating.rating, rating.races
+		FROM unnest($2::uuid[], $3::text[], $4::date[],
+		            $5::double precision[], $6::integer[])
+		    AS rating(runner_id, race, race_date, rating, races)
+    `, distance, pq.Array(runnerIDs), pq.Array(races), pq.Array(raceDates),
+		pq.Array(values), pq.Array(counts))
+	if err != nil {
+		transaction.Rollback()
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	err = transaction.Commit()
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
+
+// GetRaceDistances returns the distances of the finished results.
+func (rr RatingsRepository) GetRaceDistances(ctx context.Context) ([]int, *models.ResponseError) {
+	query := `
+		SELECT DISTINCT distance
+		FROM results
+		WHERE ` + finished + `
+		ORDER BY distance
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	distances := make([]int, 0)
+	for rows.Next() {
+		var distance int
+		err = rows.Scan(&distance)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		distances = append(distances, distance)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return distances, nil
+}
+
+// RankingsFilter selects the runners of the rankings over Distance as of
+// Date. Country and Category are ignored when empty.
+type RankingsFilter struct {
+	Distance int
+	Date     string
+	Country  string
+	Category string
+}
+
+// GetRankings ranks the runners matching filter by their latest rating as of
+// filter.Date, highest first.
+func (rr RatingsRepository) GetRankings(ctx context.Context,
+	filter RankingsFilter) ([]*models.Ranking, *models.ResponseError) {
+	query := `
+		SELECT ` + runnerColumns + `,
+		       latest.rating, latest.races, latest.race_date
+		FROM runners
+		JOIN (
+		    SELECT DISTINCT ON (runner_id) runner_id, rating, races, race_date
+		    FROM ratings
+		    WHERE distance = $1 AND race_date <= $2
+		    ORDER BY runner_id, race_date DESC, races DESC) latest
+		    ON runners.id = latest.runner_id
+		WHERE ($3 = '' OR runners.country = $3)
+		  AND ($4 = '' OR runners.category = $4)
+		ORDER BY latest.rating DESC
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, filter.Distance,
+		filter.Date, filter.Country, filter.Category)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	rankings := make([]*models.Ranking, 0)
+	for rows.Next() {
+		ranking := &models.Ranking{Rank: len(rankings) + 1}
+		var date time.Time
+		ranking.Runner, err = scanRunner(rows, &ranking.Rating, &ranking.Races, &date)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		ranking.LastRace = date.Format("2006-01-02")
+		rankings = append(rankings, ranking)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return rankings, nil
+}
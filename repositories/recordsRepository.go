@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"net/http"
+	"time"
+)
+
+type RecordsRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewRecordsRepository(dbHandler *sql.DB) *RecordsRepository {
+	return &RecordsRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+// GetCurrentRecord returns the current record of the scope and locks it
+// until transaction ends, or nil when there is none yet.
+func (rr RecordsRepository) GetCurrentRecord(ctx context.Context,
+	transaction *sql.Tx, country, category string,
+	distance int) (*models.Record, *models.ResponseError) {
+	query := `
+		SELECT id, race_result
+		FROM records
+		WHERE country = $1 AND category = $2 AND distance = $3 AND current
+		FOR UPDATE
+    `
+	rows, err := transaction.QueryContext(ctx, query, country, category, distance)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	var record *models.Record
+	for rows.Next() {
+		record = &models.Record{Country: country, Category: category, Distance: distance}
+		err = rows.Scan(&record.ID, &record.Time)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return record, nil
+}
+
+// CreateRecord makes record the current record of its scope within
+// transaction, breaking the record with previousID unless it is empty.
+func (rr RecordsRepository) CreateRecord(ctx context.Context,
+	transaction *sql.Tx, record *models.Record, previousID string) *models.ResponseError {
+	if previousID != "" {
+		_, err := transaction.ExecContext(ctx, `
+			UPDATE records
+			SET current = FALSE
+			WHERE id = $1
+        `, previousID)
+		if err != nil {
+			return &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	query := `
+		INSERT INTO records(country, category, distance, result_id,
+		                    race_result, previous_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+    `
+	err := transaction.QueryRowContext(ctx, query, record.Country,
+		record.Category, record.Distance, record.ResultID, record.Time,
+		sql.NullString{String: previousID, Valid: previousID != ""}).Scan(&record.ID)
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	record.PreviousID = previousID
+	record.Current = true
+	return nil
+}
+
+// DeleteResultRecords removes the records of a result about to be deleted
+// from their lineages. Where it held the current record, the record it broke
+// is current again; results between the two are not reconsidered.
+func (rr RecordsRepository) DeleteResultRecords(ctx context.Context,
+	transaction *sql.Tx, resultID string) *models.ResponseError {
+	query := `
+		WITH removed AS (
+		    DELETE FROM records
+		    WHERE result_id = $1
+		    RETURNING id, previous_id, current),
+		relinked AS (
+		    UPDATE records
+		    SET previous_id = removed.previous_id
+		    FROM removed
+		    WHERE records.previous_id = removed.id)
+		UPDATE records
+		SET current = TRUE
+		FROM removed
+		WHERE records.id = removed.previous_id AND removed.current
+    `
+	_, err := transaction.ExecContext(ctx, query, resultID)
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
+
+// RecordsFilter selects records. Scope is models.ScopeWorld or
+// models.ScopeNational; empty fields select all.
+type RecordsFilter struct {
+	Scope    string
+	Country  string
+	Category string
+	Distance int
+}
+
+// GetRecords returns the current and past records matching filter, current
+// records first, ordered by distance.
+func (rr RecordsRepository) GetRecords(ctx context.Context,
+	filter RecordsFilter) ([]*models.Record, *models.ResponseError) {
+	query := `
+		SELECT records.id, records.country, records.category,
+		       records.distance, records.race_result, records.result_id,
+		       records.previous_id, records.current, results.runner_id,
+		       runners.first_name || ' ' || runners.last_name,
+		       results.location, ` + raceDate + `
+		FROM records
+		JOIN results ON results.id = records.result_id
+		JOIN runners ON runners.id = results.runner_id
+		WHERE ($1 = '' OR ($1 = 'world') = (records.country = ''))
+		  AND ($2 = '' OR records.country = $2)
+		  AND ($3 = '' OR records.category = $3)
+		  AND ($4 = 0 OR records.distance = $4)
+		ORDER BY records.current DESC, records.distance, records.country,
+		         records.category, records.recognized_at DESC
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, filter.Scope,
+		filter.Country, filter.Category, filter.Distance)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	records := make([]*models.Record, 0)
+	for rows.Next() {
+		record := &models.Record{}
+		var previousID sql.NullString
+		var date time.Time
+		err = rows.Scan(&record.ID, &record.Country, &record.Category,
+			&record.Distance, &record.Time, &record.ResultID, &previousID,
+			&record.Current, &record.RunnerID, &record.RunnerName, &record.Location,
+			&date)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		record.PreviousID = previousID.String
+		record.RaceDate = date.Format("2006-01-02")
+		records = append(records, record)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return records, nil
+}
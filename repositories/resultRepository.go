@@ -1,14 +1,26 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"github.com/fentezi/runnerBook/models"
 	"net/http"
+	"time"
 )
 
+// finished restricts a query to results of runners who finished.
+const finished = "status = 'FIN'"
+
+// countsForBests restricts a query to the results that count towards bests
+// and leaderboards: finished marathons, models.MarathonDistance.
+const countsForBests = finished + " AND distance = 42195"
+
+// raceDate is the date a result counts on when assigning it to a season.
+// Results recorded without a race date count on July 1st of their year.
+const raceDate = "COALESCE(race_date, make_date(year, 7, 1))"
+
 type ResultsRepository struct {
-	dbHandler   *sql.DB
-	transaction *sql.Tx
+	dbHandler *sql.DB
 }
 
 func NewResultsRepository(dbHandler *sql.DB) *ResultsRepository {
@@ -17,15 +29,22 @@ func NewResultsRepository(dbHandler *sql.DB) *ResultsRepository {
 	}
 }
 
-func (rr ResultsRepository) CreateResult(result *models.Result) (*models.Result, *models.ResponseError) {
+func (rr ResultsRepository) CreateResult(ctx context.Context,
+	transaction *sql.Tx, result *models.Result) (*models.Result, *models.ResponseError) {
 	query := `
 		INSERT INTO results(runner_id, race_result, location,
-		                    position, year)
-		VALUES ($1, $2, $3, $4, $5)
+		                    position, year, race_date,
+		                    gun_time, chip_time, time_precision,
+		                    status, status_reason, distance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
     `
-	rows, err := rr.dbHandler.Query(query, result.RunnerID, result.RaceResult,
-		result.Location, result.Position, result.Year)
+	rows, err := transaction.QueryContext(ctx, query, result.RunnerID, result.RaceResult,
+		result.Location, result.Position, result.Year,
+		sql.NullString{String: result.RaceDate, Valid: result.RaceDate != ""},
+		result.GunTime, result.ChipTime, result.Precision, result.Status,
+		sql.NullString{String: result.StatusReason, Valid: result.StatusReason != ""},
+		result.Distance)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -50,21 +69,29 @@ func (rr ResultsRepository) CreateResult(result *models.Result) (*models.Result,
 		}
 	}
 	return &models.Result{
-		ID:         resultID,
-		RunnerID:   result.RunnerID,
-		RaceResult: result.RaceResult,
-		Location:   result.Location,
-		Position:   result.Position,
-		Year:       result.Year,
+		ID:           resultID,
+		RunnerID:     result.RunnerID,
+		RaceResult:   result.RaceResult,
+		Location:     result.Location,
+		Position:     result.Position,
+		Year:         result.Year,
+		RaceDate:     result.RaceDate,
+		GunTime:      result.GunTime,
+		ChipTime:     result.ChipTime,
+		Precision:    result.Precision,
+		Status:       result.Status,
+		StatusReason: result.StatusReason,
+		Distance:     result.Distance,
 	}, nil
 }
 
-func (rr ResultsRepository) DeleteResult(resultID string) (*models.Result, *models.ResponseError) {
+func (rr ResultsRepository) DeleteResult(ctx context.Context,
+	transaction *sql.Tx, resultID string) (*models.Result, *models.ResponseError) {
 	query := `
 		DELETE FROM results
 		WHERE id = $1
-		RETURNING runner_id, race_result, year`
-	rows, err := rr.transaction.Query(query, resultID)
+		RETURNING runner_id, race_result, year, race_date, status, distance`
+	rows, err := transaction.QueryContext(ctx, query, resultID)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -72,10 +99,13 @@ func (rr ResultsRepository) DeleteResult(resultID string) (*models.Result, *mode
 		}
 	}
 	defer rows.Close()
-	var runner_id, raceResult string
-	var year int
+	var runner_id string
+	var raceResult models.RaceTime
+	var year, distance int
+	var date sql.NullTime
+	var status string
 	for rows.Next() {
-		err = rows.Scan(&runner_id, &raceResult, &year)
+		err = rows.Scan(&runner_id, &raceResult, &year, &date, &status, &distance)
 		if err != nil {
 			return nil, &models.ResponseError{
 				Message: err.Error(),
@@ -94,17 +124,87 @@ func (rr ResultsRepository) DeleteResult(resultID string) (*models.Result, *mode
 		RunnerID:   runner_id,
 		RaceResult: raceResult,
 		Year:       year,
+		RaceDate:   formatDate(date),
+		Status:     status,
+		Distance:   distance,
 	}, nil
 }
 
-func (rr ResultsRepository) GetAllRunnersResults(
+// GetResult returns the result with resultID, or nil when there is none.
+func (rr ResultsRepository) GetResult(ctx context.Context,
+	resultID string) (*models.Result, *models.ResponseError) {
+	query := `
+		SELECT runner_id, race_result, location, position, year, race_date,
+		       gun_time, chip_time, time_precision, status, status_reason,
+		       distance
+		FROM results
+		WHERE id = $1
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, resultID)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	var result *models.Result
+	var runnerID, location, status string
+	var raceResult, gunTime, chipTime models.RaceTime
+	var position, year, precision, distance int
+	var date sql.NullTime
+	var statusReason sql.NullString
+	for rows.Next() {
+		err = rows.Scan(&runnerID, &raceResult, &location, &position, &year,
+			&date, &gunTime, &chipTime, &precision, &status, &statusReason,
+			&distance)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		result = &models.Result{
+			ID:           resultID,
+			RunnerID:     runnerID,
+			RaceResult:   raceResult,
+			Location:     location,
+			Position:     position,
+			Year:         year,
+			RaceDate:     formatDate(date),
+			GunTime:      gunTime,
+			ChipTime:     chipTime,
+			Precision:    precision,
+			Status:       status,
+			StatusReason: statusReason.String,
+			Distance:     distance,
+		}
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return result, nil
+}
+
+// GetAllRunnersResults returns the results of a runner, each with the club
+// the runner belonged to on the race date.
+func (rr ResultsRepository) GetAllRunnersResults(ctx context.Context,
 	runnerID string) ([]*models.Result, *models.ResponseError) {
 	query := `
-    	SELECT id, race_result, location, position, year
+    	SELECT results.id, results.race_result, results.location,
+    	       results.position, results.year, results.race_date,
+    	       results.gun_time, results.chip_time, results.time_precision,
+    	       results.status, results.status_reason, results.distance,
+    	       clubs.id, clubs.name
 		FROM results
-    	WHERE runner_id = $1
+		LEFT JOIN club_memberships ON ` + memberOnRaceDate + `
+		LEFT JOIN clubs ON clubs.id = club_memberships.club_id
+    	WHERE results.runner_id = $1
     `
-	rows, err := rr.dbHandler.Query(query, runnerID)
+	rows, err := rr.dbHandler.QueryContext(ctx, query, runnerID)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -113,25 +213,39 @@ func (rr ResultsRepository) GetAllRunnersResults(
 	}
 	defer rows.Close()
 	results := make([]*models.Result, 0)
-	var id, raceResult, location string
-	var position, year int
+	var id, location, status string
+	var raceResult, gunTime, chipTime models.RaceTime
+	var position, year, precision, distance int
+	var date sql.NullTime
+	var statusReason, clubID, club sql.NullString
 	for rows.Next() {
-		err = rows.Scan(&id, &raceResult, &location, &position, &year)
+		err = rows.Scan(&id, &raceResult, &location, &position, &year, &date,
+			&gunTime, &chipTime, &precision, &status, &statusReason, &distance,
+			&clubID, &club)
 		if err != nil {
 			return nil, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
-			result := &models.Result{
-				ID:         id,
-				RunnerID:   runnerID,
-				RaceResult: raceResult,
-				Location:   location,
-				Position:   position,
-				Year:       year,
-			}
-			results = append(results, result)
 		}
+		result := &models.Result{
+			ID:           id,
+			RunnerID:     runnerID,
+			RaceResult:   raceResult,
+			Location:     location,
+			Position:     position,
+			Year:         year,
+			RaceDate:     formatDate(date),
+			GunTime:      gunTime,
+			ChipTime:     chipTime,
+			Precision:    precision,
+			Status:       status,
+			StatusReason: statusReason.String,
+			Distance:     distance,
+			ClubID:       clubID.String,
+			Club:         club.String,
+		}
+		results = append(results, result)
 	}
 	if rows.Err() != nil {
 		return nil, &models.ResponseError{
@@ -142,33 +256,35 @@ func (rr ResultsRepository) GetAllRunnersResults(
 	return results, nil
 }
 
-func (rr ResultsRepository) GetPersonalBestResults(
-	runnerID string) (string, *models.ResponseError) {
+// GetPersonalBestResults returns the best result of the runner, as seen by
+// transaction, so that a result it deleted no longer counts.
+func (rr ResultsRepository) GetPersonalBestResults(ctx context.Context,
+	transaction *sql.Tx, runnerID string) (models.RaceTime, *models.ResponseError) {
 	query := `
 		SELECT MIN(race_result)
 		FROM results
-		WHERE runner_id = $1
+		WHERE runner_id = $1 AND ` + countsForBests + `
     `
-	rows, err := rr.dbHandler.Query(query, runnerID)
+	rows, err := transaction.QueryContext(ctx, query, runnerID)
 	if err != nil {
-		return "", &models.ResponseError{
+		return 0, &models.ResponseError{
 			Message: err.Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	defer rows.Close()
-	var raceResult string
+	var raceResult models.RaceTime
 	for rows.Next() {
 		err = rows.Scan(&raceResult)
 		if err != nil {
-			return "", &models.ResponseError{
+			return 0, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
 		}
 	}
 	if rows.Err() != nil {
-		return "", &models.ResponseError{
+		return 0, &models.ResponseError{
 			Message: err.Error(),
 			Status:  http.StatusInternalServerError,
 		}
@@ -176,36 +292,135 @@ func (rr ResultsRepository) GetPersonalBestResults(
 	return raceResult, nil
 }
 
-func (rr ResultsRepository) GetSeasonBestResults(
-	runnerID string, year int) (string, *models.ResponseError) {
+// GetSeasonBestResults returns the best result of the runner in the season
+// from seasonStart (inclusive) to seasonEnd (exclusive), both "YYYY-MM-DD",
+// as seen by transaction.
+func (rr ResultsRepository) GetSeasonBestResults(ctx context.Context,
+	transaction *sql.Tx, runnerID, seasonStart, seasonEnd string) (models.RaceTime, *models.ResponseError) {
 	query := `
     	SELECT MIN(race_result)
 		FROM results
-    	WHERE runner_id = $1 AND year = $2
+    	WHERE runner_id = $1 AND ` + countsForBests + `
+    	  AND ` + raceDate + ` >= $2
+    	  AND ` + raceDate + ` < $3
     `
-	rows, err := rr.dbHandler.Query(query, runnerID, year)
+	rows, err := transaction.QueryContext(ctx, query, runnerID,
+		seasonStart, seasonEnd)
 	if err != nil {
-		return "", &models.ResponseError{
+		return 0, &models.ResponseError{
 			Message: err.Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	defer rows.Close()
-	var raceResult string
+	var raceResult models.RaceTime
 	for rows.Next() {
 		err = rows.Scan(&raceResult)
 		if err != nil {
-			return "", &models.ResponseError{
+			return 0, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
 		}
 	}
 	if rows.Err() != nil {
-		return "", &models.ResponseError{
+		return 0, &models.ResponseError{
 			Message: err.Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	return raceResult, nil
 }
+
+func formatDate(date sql.NullTime) string {
+	if !date.Valid {
+		return ""
+	}
+	return date.Time.Format("2006-01-02")
+}
+
+// CreateResultNote adds a note by the user with authorID to a result.
+func (rr ResultsRepository) CreateResultNote(ctx context.Context,
+	note *models.ResultNote, authorID string) (*models.ResultNote, *models.ResponseError) {
+	query := `
+		INSERT INTO result_notes(result_id, author_id, note)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, note.ResultID,
+		authorID, note.Note)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	created := &models.ResultNote{
+		ResultID: note.ResultID,
+		Author:   note.Author,
+		Note:     note.Note,
+	}
+	for rows.Next() {
+		var createdAt time.Time
+		err = rows.Scan(&created.ID, &createdAt)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		created.CreatedAt = createdAt.Format(time.RFC3339)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return created, nil
+}
+
+// GetResultNotes returns the notes of a result, oldest first.
+func (rr ResultsRepository) GetResultNotes(ctx context.Context,
+	resultID string) ([]*models.ResultNote, *models.ResponseError) {
+	query := `
+		SELECT result_notes.id, users.username, result_notes.note,
+		       result_notes.created_at
+		FROM result_notes
+		LEFT JOIN users ON users.id = result_notes.author_id
+		WHERE result_notes.result_id = $1
+		ORDER BY result_notes.created_at
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, resultID)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	notes := make([]*models.ResultNote, 0)
+	for rows.Next() {
+		note := &models.ResultNote{ResultID: resultID}
+		var author sql.NullString
+		var createdAt time.Time
+		err = rows.Scan(&note.ID, &author, &note.Note, &createdAt)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		note.Author = author.String
+		note.CreatedAt = createdAt.Format(time.RFC3339)
+		notes = append(notes, note)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return notes, nil
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+type RolesRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewRolesRepository(dbHandler *sql.DB) *RolesRepository {
+	return &RolesRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+// GetPermissions returns every permission, ordered by name.
+func (rr RolesRepository) GetPermissions(ctx context.Context) ([]*models.Permission, *models.ResponseError) {
+	query := `
+		SELECT name, description
+		FROM permissions
+		ORDER BY name
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	permissions := make([]*models.Permission, 0)
+	for rows.Next() {
+		permission := &models.Permission{}
+		err = rows.Scan(&permission.Name, &permission.Description)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		permissions = append(permissions, permission)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return permissions, nil
+}
+
+// GetRole returns the role named name with its permissions, or nil when
+// there is none.
+func (rr RolesRepository) GetRole(ctx context.Context,
+	name string) (*models.Role, *models.ResponseError) {
+	roles, responseErr := rr.getRoles(ctx, "WHERE roles.name = $1", name)
+	if responseErr != nil || len(roles) == 0 {
+		return nil, responseErr
+	}
+	return roles[0], nil
+}
+
+// GetRoles returns every role with its permissions, ordered by name.
+func (rr RolesRepository) GetRoles(ctx context.Context) ([]*models.Role, *models.ResponseError) {
+	return rr.getRoles(ctx, "")
+}
+
+func (rr RolesRepository) getRoles(ctx context.Context, where string,
+	args ...any) ([]*models.Role, *models.ResponseError) {
+	query := `
+		SELECT roles.name,
+		       COALESCE(array_agg(role_permissions.permission
+		                          ORDER BY role_permissions.permission)
+		           FILTER (WHERE role_permissions.permission IS NOT NULL), '{}')
+		FROM roles
+		LEFT JOIN role_permissions ON role_permissions.role = roles.name
+		` + where + `
+		GROUP BY roles.name
+		ORDER BY roles.name
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	roles := make([]*models.Role, 0)
+	for rows.Next() {
+		role := &models.Role{}
+		err = rows.Scan(&role.Name, pq.Array(&role.Permissions))
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		roles = append(roles, role)
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return roles, nil
+}
+
+// CreateRole creates a role granting its permissions.
+func (rr RolesRepository) CreateRole(ctx context.Context,
+	role *models.Role) *models.ResponseError {
+	return rr.inTransaction(ctx, "Role already exists",
+		func(transaction *sql.Tx) error {
+			_, err := transaction.ExecContext(ctx, `
+			INSERT INTO roles(name)
+			VALUES ($1)
+        `, role.Name)
+			if err != nil {
+				return err
+			}
+			return grantPermissions(ctx, transaction, role)
+		})
+}
+
+// SetRolePermissions replaces the permissions of a role. Users with the role
+// have the new permissions from their next request on.
+func (rr RolesRepository) SetRolePermissions(ctx context.Context,
+	role *models.Role) *models.ResponseError {
+	return rr.inTransaction(ctx, "Role was changed concurrently",
+		func(transaction *sql.Tx) error {
+			_, err := transaction.ExecContext(ctx, `
+			DELETE FROM role_permissions
+			WHERE role = $1
+        `, role.Name)
+			if err != nil {
+				return err
+			}
+			return grantPermissions(ctx, transaction, role)
+		})
+}
+
+func (rr RolesRepository) DeleteRole(ctx context.Context,
+	name string) *models.ResponseError {
+	query := `
+		DELETE FROM roles
+		WHERE name = $1
+    `
+	res, err := rr.dbHandler.ExecContext(ctx, query, name)
+	if violates(err, foreignKeyViolation) {
+		return &models.ResponseError{
+			Message: "Role is in use",
+			Status:  http.StatusConflict,
+		}
+	}
+	return affectedOne(res, err, "Role not found")
+}
+
+// CountUsers returns the number of users with the role named name.
+func (rr RolesRepository) CountUsers(ctx context.Context,
+	name string) (int, *models.ResponseError) {
+	query := `
+		SELECT COUNT(*)
+		FROM users
+		WHERE user_role = $1
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, name)
+	if err != nil {
+		return 0, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	var count int
+	for rows.Next() {
+		err = rows.Scan(&count)
+		if err != nil {
+			return 0, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return 0, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return count, nil
+}
+
+// inTransaction runs statements in a transaction, reporting a violated
+// unique constraint as a conflict with message.
+func (rr RolesRepository) inTransaction(ctx context.Context, conflict string,
+	statements func(transaction *sql.Tx) error) *models.ResponseError {
+	transaction, err := rr.dbHandler.BeginTx(ctx, &sql.TxOptions{})
+	if err == nil {
+		err = statements(transaction)
+		if err != nil {
+			transaction.Rollback()
+		} else {
+			err = transaction.Commit()
+		}
+	}
+	if err != nil {
+		return conflictError(err, conflict)
+	}
+	return nil
+}
+
+func grantPermissions(ctx context.Context, transaction *sql.Tx,
+	role *models.Role) error {
+	_, err := transaction.ExecContext(ctx, `
+		INSERT INTO role_permissions(role, permission)
+		SELECT $1, unnest($2::text[])
+    `, role.Name, pq.Array(role.Permissions))
+	return err
+}
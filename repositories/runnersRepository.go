@@ -1,14 +1,15 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"github.com/fentezi/runnerBook/models"
 	"net/http"
+	"time"
 )
 
 type RunnersRepository struct {
-	dbHandler   *sql.DB
-	transaction *sql.Tx
+	dbHandler *sql.DB
 }
 
 func NewRunnersRepository(dbHandler *sql.DB) *RunnersRepository {
@@ -17,14 +18,17 @@ func NewRunnersRepository(dbHandler *sql.DB) *RunnersRepository {
 	}
 }
 
-func (rr RunnersRepository) CreateRunner(
+func (rr RunnersRepository) CreateRunner(ctx context.Context,
 	runner *models.Runner) (*models.Runner, *models.ResponseError) {
 	query := `
-		INSERT INTO runners(first_name, last_name, age, country)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO runners(first_name, last_name, age, country,
+		                    category, date_of_birth)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	RETURNING id`
-	rows, err := rr.dbHandler.Query(query, runner.FirstName,
-		runner.LastName, runner.Age, runner.Country)
+	rows, err := rr.dbHandler.QueryContext(ctx, query, runner.FirstName,
+		runner.LastName, runner.Age, runner.Country,
+		sql.NullString{String: runner.Category, Valid: runner.Category != ""},
+		sql.NullString{String: runner.DateOfBirth, Valid: runner.DateOfBirth != ""})
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -49,16 +53,18 @@ func (rr RunnersRepository) CreateRunner(
 		}
 	}
 	return &models.Runner{
-		ID:        runnerID,
-		FirstName: runner.FirstName,
-		LastName:  runner.LastName,
-		Age:       runner.Age,
-		IsActive:  true,
-		Country:   runner.Country,
+		ID:          runnerID,
+		FirstName:   runner.FirstName,
+		LastName:    runner.LastName,
+		Age:         runner.Age,
+		IsActive:    true,
+		Country:     runner.Country,
+		Category:    runner.Category,
+		DateOfBirth: runner.DateOfBirth,
 	}, nil
 }
 
-func (rr RunnersRepository) UpdateRunner(
+func (rr RunnersRepository) UpdateRunner(ctx context.Context,
 	runner *models.Runner) *models.ResponseError {
 	query := `
 		UPDATE runners
@@ -66,10 +72,15 @@ func (rr RunnersRepository) UpdateRunner(
 		    first_name = $1,
 		    last_name = $2,
 		    age = $3,
-		    country = $4
-		    WHERE id = $5`
-	res, err := rr.dbHandler.Exec(query, runner.FirstName,
-		runner.LastName, runner.Age, runner.Country, runner.ID)
+		    country = $4,
+		    category = $5,
+		    date_of_birth = $6
+		    WHERE id = $7`
+	res, err := rr.dbHandler.ExecContext(ctx, query, runner.FirstName,
+		runner.LastName, runner.Age, runner.Country,
+		sql.NullString{String: runner.Category, Valid: runner.Category != ""},
+		sql.NullString{String: runner.DateOfBirth, Valid: runner.DateOfBirth != ""},
+		runner.ID)
 	if err != nil {
 		return &models.ResponseError{
 			Message: err.Error(),
@@ -92,7 +103,10 @@ func (rr RunnersRepository) UpdateRunner(
 	return nil
 }
 
-func (rr RunnersRepository) UpdateRunnerResults(runner *models.Runner) *models.ResponseError {
+// UpdateRunnerResults stores the personal and season best of runner within
+// transaction.
+func (rr RunnersRepository) UpdateRunnerResults(ctx context.Context,
+	transaction *sql.Tx, runner *models.Runner) *models.ResponseError {
 	query := `
 		UPDATE runners
 		SET
@@ -100,7 +114,8 @@ func (rr RunnersRepository) UpdateRunnerResults(runner *models.Runner) *models.R
 		    season_best = $2
 		WHERE id = $3
     `
-	_, err := rr.transaction.Exec(query, runner.PersonalBest, runner.SeasonBest, runner.ID)
+	_, err := transaction.ExecContext(ctx, query,
+		runner.PersonalBest, runner.SeasonBest, runner.ID)
 	if err != nil {
 		return &models.ResponseError{
 			Message: err.Error(),
@@ -110,14 +125,14 @@ func (rr RunnersRepository) UpdateRunnerResults(runner *models.Runner) *models.R
 	return nil
 }
 
-func (rr RunnersRepository) DeleteRunner(runnerID string) *models.ResponseError {
+func (rr RunnersRepository) DeleteRunner(ctx context.Context, runnerID string) *models.ResponseError {
 	query := `
 		UPDATE runners
 		SET
 		    is_active = 'false'
 		WHERE id = $1
     `
-	res, err := rr.dbHandler.Exec(query, runnerID)
+	res, err := rr.dbHandler.ExecContext(ctx, query, runnerID)
 	if err != nil {
 		return &models.ResponseError{
 			Message: err.Error(),
@@ -140,13 +155,42 @@ func (rr RunnersRepository) DeleteRunner(runnerID string) *models.ResponseError
 	return nil
 }
 
-func (rr RunnersRepository) GetRunner(runnerID string) (*models.Runner, *models.ResponseError) {
+// runnerColumns are the columns scanRunner reads, in order.
+const runnerColumns = `runners.id, runners.first_name, runners.last_name,
+	runners.age, runners.is_active, runners.country, runners.personal_best,
+	runners.season_best, runners.category, runners.date_of_birth`
+
+// scanRunner reads runnerColumns followed by extra destinations. The age of
+// runners with a date of birth is computed as of today.
+func scanRunner(rows *sql.Rows, extra ...any) (*models.Runner, error) {
+	runner := &models.Runner{}
+	var age sql.NullInt64
+	var category sql.NullString
+	var dateOfBirth sql.NullTime
+	destinations := append([]any{&runner.ID, &runner.FirstName,
+		&runner.LastName, &age, &runner.IsActive, &runner.Country,
+		&runner.PersonalBest, &runner.SeasonBest, &category, &dateOfBirth},
+		extra...)
+	err := rows.Scan(destinations...)
+	if err != nil {
+		return nil, err
+	}
+	runner.Age = int(age.Int64)
+	runner.Category = category.String
+	runner.DateOfBirth = formatDate(dateOfBirth)
+	if dateOfBirth.Valid {
+		runner.Age = models.AgeOn(dateOfBirth.Time, time.Now())
+	}
+	return runner, nil
+}
+
+func (rr RunnersRepository) GetRunner(ctx context.Context, runnerID string) (*models.Runner, *models.ResponseError) {
 	query := `
-		SELECT *
+		SELECT ` + runnerColumns + `
 		FROM runners
 		WHERE id = $1
     `
-	rows, err := rr.dbHandler.Query(query, runnerID)
+	rows, err := rr.dbHandler.QueryContext(ctx, query, runnerID)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -154,13 +198,9 @@ func (rr RunnersRepository) GetRunner(runnerID string) (*models.Runner, *models.
 		}
 	}
 	defer rows.Close()
-	var id, firstName, lastName, country string
-	var personalBest, seasonBest sql.NullString
-	var age int
-	var isActive bool
+	runner := &models.Runner{}
 	for rows.Next() {
-		err := rows.Scan(&id, &firstName, &lastName, &age,
-			&isActive, &country, &personalBest, &seasonBest)
+		runner, err = scanRunner(rows)
 		if err != nil {
 			return nil, &models.ResponseError{
 				Message: err.Error(),
@@ -170,28 +210,19 @@ func (rr RunnersRepository) GetRunner(runnerID string) (*models.Runner, *models.
 	}
 	if rows.Err() != nil {
 		return nil, &models.ResponseError{
-			Message: err.Error(),
+			Message: rows.Err().Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
-	return &models.Runner{
-		ID:           id,
-		FirstName:    firstName,
-		LastName:     lastName,
-		Age:          age,
-		IsActive:     isActive,
-		Country:      country,
-		PersonalBest: personalBest.String,
-		SeasonBest:   seasonBest.String,
-	}, nil
+	return runner, nil
 }
 
-func (rr RunnersRepository) GetAllRunners() ([]*models.Runner, *models.ResponseError) {
+func (rr RunnersRepository) GetAllRunners(ctx context.Context) ([]*models.Runner, *models.ResponseError) {
 	query := `
-		SELECT *
+		SELECT ` + runnerColumns + `
 		FROM runners
     `
-	rows, err := rr.dbHandler.Query(query)
+	rows, err := rr.dbHandler.QueryContext(ctx, query)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -200,49 +231,60 @@ func (rr RunnersRepository) GetAllRunners() ([]*models.Runner, *models.ResponseE
 	}
 	defer rows.Close()
 	runners := make([]*models.Runner, 0)
-	var id, firstName, lastName, country string
-	var personalBest, seasonBest sql.NullString
-	var age int
-	var isActive bool
 	for rows.Next() {
-		err := rows.Scan(&id, &firstName, &lastName, &age,
-			&isActive, &country, &personalBest, &seasonBest)
+		runner, err := scanRunner(rows)
 		if err != nil {
 			return nil, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
 		}
-		runner := &models.Runner{
-			ID:           id,
-			FirstName:    firstName,
-			LastName:     lastName,
-			Age:          age,
-			IsActive:     isActive,
-			Country:      country,
-			PersonalBest: personalBest.String,
-			SeasonBest:   seasonBest.String,
-		}
 		runners = append(runners, runner)
 	}
 	if rows.Err() != nil {
 		return nil, &models.ResponseError{
-			Message: err.Error(),
+			Message: rows.Err().Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	return runners, nil
 }
 
-func (rr RunnersRepository) GetRunnersByCountry(country string) ([]*models.Runner, *models.ResponseError) {
+// LeaderboardFilter narrows a leaderboard to a competition category and to
+// results run at an age from MinAge to MaxAge. A zero MaxAge does not filter
+// by age.
+type LeaderboardFilter struct {
+	Category string
+	MinAge   int
+	MaxAge   int
+}
+
+// leaderboard ranks runners by their best marathon among the results
+// matching condition, which may use the parameters from $4 on, and filter.
+// The best time is returned in field, the age group as of its race date.
+func (rr RunnersRepository) leaderboard(ctx context.Context, condition string,
+	filter LeaderboardFilter, field func(*models.Runner) *models.RaceTime,
+	args ...any) ([]*models.Runner, *models.ResponseError) {
 	query := `
-		SELECT *
+		SELECT ` + runnerColumns + `,
+			bests.race_result, bests.race_date
 		FROM runners
-		WHERE country = $1 AND is_active = 'true'
-		ORDER BY personal_best
+		JOIN (
+		    SELECT DISTINCT ON (results.runner_id) results.runner_id,
+		           results.race_result, ` + raceDate + ` AS race_date
+		    FROM results
+		    JOIN runners ON runners.id = results.runner_id
+		    WHERE ` + countsForBests + ` AND ` + condition + `
+		      AND ($1 = '' OR runners.category = $1)
+		      AND ($3 = 0 OR date_part('year',
+		          age(` + raceDate + `, runners.date_of_birth)) BETWEEN $2 AND $3)
+		    ORDER BY results.runner_id, results.race_result) bests
+		    ON runners.id = bests.runner_id
+		ORDER BY bests.race_result
 		LIMIT 10
     `
-	rows, err := rr.dbHandler.Query(query, country)
+	args = append([]any{filter.Category, filter.MinAge, filter.MaxAge}, args...)
+	rows, err := rr.dbHandler.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -251,57 +293,185 @@ func (rr RunnersRepository) GetRunnersByCountry(country string) ([]*models.Runne
 	}
 	defer rows.Close()
 	runners := make([]*models.Runner, 0)
-	var id, firstName, lastName string
-	var personalBest, seasonBest sql.NullString
-	var age int
 	for rows.Next() {
-		err = rows.Scan(&id, &firstName, &lastName, &age,
-			&personalBest, &seasonBest)
+		var best models.RaceTime
+		var date time.Time
+		runner, err := scanRunner(rows, &best, &date)
 		if err != nil {
 			return nil, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
 		}
-		runner := &models.Runner{
-			ID:           id,
-			FirstName:    firstName,
-			LastName:     lastName,
-			Age:          age,
-			IsActive:     true,
-			Country:      country,
-			PersonalBest: personalBest.String,
-			SeasonBest:   seasonBest.String,
+		*field(runner) = best
+		if runner.Category != "" && runner.DateOfBirth != "" {
+			dateOfBirth, _ := time.Parse("2006-01-02", runner.DateOfBirth)
+			runner.AgeGroup = models.AgeGroup(runner.Category,
+				models.AgeOn(dateOfBirth, date))
 		}
 		runners = append(runners, runner)
 	}
 	if rows.Err() != nil {
 		return nil, &models.ResponseError{
-			Message: err.Error(),
+			Message: rows.Err().Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	return runners, nil
 }
 
-func (rr RunnersRepository) GetRunnersByYear(year int) ([]*models.Runner, *models.ResponseError) {
+// GetRunnersByCountry returns the ten fastest active runners of a country.
+// Their personal best is the best result matching filter.
+func (rr RunnersRepository) GetRunnersByCountry(ctx context.Context,
+	country string, filter LeaderboardFilter) ([]*models.Runner, *models.ResponseError) {
+	return rr.leaderboard(ctx, "runners.country = $4 AND runners.is_active",
+		filter, func(runner *models.Runner) *models.RaceTime {
+			return &runner.PersonalBest
+		}, country)
+}
+
+// GetRunnersByYear returns the ten runners with the fastest results in a
+// year. Their season best is their best result of that year matching filter.
+func (rr RunnersRepository) GetRunnersByYear(ctx context.Context,
+	year int, filter LeaderboardFilter) ([]*models.Runner, *models.ResponseError) {
+	return rr.leaderboard(ctx, "results.year = $4",
+		filter, func(runner *models.Runner) *models.RaceTime {
+			return &runner.SeasonBest
+		}, year)
+}
+
+// GetDriftedBests returns the runners whose stored personal or season best
+// differs from the best results recorded for them, limited to runnerID
+// unless it is empty. The season runs from seasonStart (inclusive) to
+// seasonEnd (exclusive), both "YYYY-MM-DD".
+func (rr RunnersRepository) GetDriftedBests(ctx context.Context,
+	runnerID, seasonStart, seasonEnd string) ([]*models.BestsDrift, *models.ResponseError) {
 	query := `
-		SELECT runners.id, runners.first_name,
-			runners.last_name, runners.age, runners.is_active,
-			runners.country, runners.personal_best,
-			results.race_result
+		SELECT runners.id, runners.first_name, runners.last_name,
+			runners.personal_best, bests.personal_best,
+			runners.season_best, bests.season_best
 		FROM runners
-		JOIN (
+		LEFT JOIN (
 		    SELECT runner_id,
-		           MIN(race_result) as race_result
+		           MIN(race_result) AS personal_best,
+		           MIN(race_result) FILTER (
+		               WHERE ` + raceDate + ` >= $1
+		                 AND ` + raceDate + ` < $2) AS season_best
 		    FROM results
-		    WHERE year = $1
-		    GROUP BY runner_id) results
-		    ON runners.id = results.runner_id
-		    ORDER BY results.race_result
-		    LIMIT 10
+		    WHERE ` + countsForBests + `
+		    GROUP BY runner_id) bests
+		    ON runners.id = bests.runner_id
+		WHERE ($3 = '' OR runners.id::text = $3)
+		  AND (runners.personal_best IS DISTINCT FROM bests.personal_best
+		    OR runners.season_best IS DISTINCT FROM bests.season_best)
+		ORDER BY runners.last_name, runners.first_name
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query,
+		seasonStart, seasonEnd, runnerID)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	drifted := make([]*models.BestsDrift, 0)
+	var id, firstName, lastName string
+	var storedPersonalBest, personalBest models.RaceTime
+	var storedSeasonBest, seasonBest models.RaceTime
+	for rows.Next() {
+		err := rows.Scan(&id, &firstName, &lastName,
+			&storedPersonalBest, &personalBest,
+			&storedSeasonBest, &seasonBest)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		drifted = append(drifted, &models.BestsDrift{
+			RunnerID:           id,
+			FirstName:          firstName,
+			LastName:           lastName,
+			StoredPersonalBest: storedPersonalBest,
+			PersonalBest:       personalBest,
+			StoredSeasonBest:   storedSeasonBest,
+			SeasonBest:         seasonBest,
+		})
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return drifted, nil
+}
+
+// ResetSeasonBests sets the season best of every runner to their best result
+// in the season from seasonStart (inclusive) to seasonEnd (exclusive), both
+// "YYYY-MM-DD", clearing it for runners without results in that season. It
+// returns the number of runners whose season best changed.
+func (rr RunnersRepository) ResetSeasonBests(ctx context.Context,
+	seasonStart, seasonEnd string) (int64, *models.ResponseError) {
+	query := `
+		WITH bests AS (
+		    SELECT runners.id,
+		           MIN(results.race_result) AS season_best
+		    FROM runners
+		    LEFT JOIN results
+		        ON results.runner_id = runners.id
+		       AND ` + countsForBests + `
+		       AND ` + raceDate + ` >= $1
+		       AND ` + raceDate + ` < $2
+		    GROUP BY runners.id)
+		UPDATE runners
+		SET season_best = bests.season_best
+		FROM bests
+		WHERE runners.id = bests.id
+		  AND runners.season_best IS DISTINCT FROM bests.season_best
     `
-	rows, err := rr.dbHandler.Query(query, year)
+	res, err := rr.dbHandler.ExecContext(ctx, query, seasonStart, seasonEnd)
+	if err != nil {
+		return 0, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return rowsAffected, nil
+}
+
+// GetRankingResults returns the runners with a category and date of birth
+// and their finished results at any distance, for ranking by age grade. The
+// runners are limited to the active runners of country and the results to
+// year, unless they are empty or zero, and both to filter.
+func (rr RunnersRepository) GetRankingResults(ctx context.Context, country string,
+	year int, filter LeaderboardFilter) ([]*models.Runner, *models.ResponseError) {
+	query := `
+		SELECT ` + runnerColumns + `,
+			results.id, results.race_result, results.distance,
+			results.location, results.year, results.race_date
+		FROM results
+		JOIN runners ON runners.id = results.runner_id
+		WHERE ` + finished + `
+		  AND runners.category IS NOT NULL
+		  AND runners.date_of_birth IS NOT NULL
+		  AND ($1 = '' OR runners.category = $1)
+		  AND ($3 = 0 OR date_part('year',
+		      age(` + raceDate + `, runners.date_of_birth)) BETWEEN $2 AND $3)
+		  AND ($4 = '' OR (runners.country = $4 AND runners.is_active))
+		  AND ($5 = 0 OR results.year = $5)
+		ORDER BY runners.id
+    `
+	rows, err := rr.dbHandler.QueryContext(ctx, query, filter.Category,
+		filter.MinAge, filter.MaxAge, country, year)
 	if err != nil {
 		return nil, &models.ResponseError{
 			Message: err.Error(),
@@ -310,37 +480,31 @@ func (rr RunnersRepository) GetRunnersByYear(year int) ([]*models.Runner, *model
 	}
 	defer rows.Close()
 	runners := make([]*models.Runner, 0)
-	var id, firstName, lastName, country string
-	var personalBest, seasonBest sql.NullString
-	var age int
-	var isActive bool
 	for rows.Next() {
-		err := rows.Scan(&id, &firstName, &lastName, &age,
-			&isActive, &country, &personalBest, &seasonBest)
+		result := &models.Result{Status: models.StatusFinished}
+		var date sql.NullTime
+		runner, err := scanRunner(rows, &result.ID, &result.RaceResult,
+			&result.Distance, &result.Location, &result.Year, &date)
 		if err != nil {
 			return nil, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
 		}
-		runner := &models.Runner{
-			ID:           id,
-			FirstName:    firstName,
-			LastName:     lastName,
-			Age:          age,
-			IsActive:     isActive,
-			Country:      country,
-			PersonalBest: personalBest.String,
-			SeasonBest:   seasonBest.String,
+		result.RunnerID = runner.ID
+		result.RaceDate = formatDate(date)
+		if len(runners) > 0 && runners[len(runners)-1].ID == runner.ID {
+			runner = runners[len(runners)-1]
+		} else {
+			runners = append(runners, runner)
 		}
-		runners = append(runners, runner)
+		runner.Results = append(runner.Results, result)
 	}
 	if rows.Err() != nil {
 		return nil, &models.ResponseError{
-			Message: err.Error(),
+			Message: rows.Err().Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	return runners, nil
-
 }
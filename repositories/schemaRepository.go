@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type SchemaRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewSchemaRepository(dbHandler *sql.DB) *SchemaRepository {
+	return &SchemaRepository{dbHandler: dbHandler}
+}
+
+func (sr SchemaRepository) Ping(ctx context.Context) *models.ResponseError {
+	err := sr.dbHandler.PingContext(ctx)
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusServiceUnavailable,
+		}
+	}
+	return nil
+}
+
+func (sr SchemaRepository) GetSchemaVersion(ctx context.Context) (int, *models.ResponseError) {
+	query := `
+		SELECT COALESCE(MAX(version), 0)
+		FROM schema_migrations
+    `
+	rows, err := sr.dbHandler.QueryContext(ctx, query)
+	if err != nil {
+		return 0, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusServiceUnavailable,
+		}
+	}
+	defer rows.Close()
+	var version int
+	for rows.Next() {
+		err = rows.Scan(&version)
+		if err != nil {
+			return 0, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return 0, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return version, nil
+}
+
+// CreateMigrationsTable creates schema_migrations unless it exists and
+// reports whether it did.
+func (sr SchemaRepository) CreateMigrationsTable(ctx context.Context) (bool, *models.ResponseError) {
+	exists, responseErr := sr.TableExists(ctx, "schema_migrations")
+	if responseErr != nil || exists {
+		return false, responseErr
+	}
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+		    version    integer     NOT NULL,
+		    applied_at timestamptz NOT NULL DEFAULT now(),
+		    CONSTRAINT schema_migrations_pk PRIMARY KEY (version)
+		)
+    `
+	_, err := sr.dbHandler.ExecContext(ctx, query)
+	if err != nil {
+		return false, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return true, nil
+}
+
+// TableExists reports whether the table named table exists in the search
+// path.
+func (sr SchemaRepository) TableExists(ctx context.Context,
+	table string) (bool, *models.ResponseError) {
+	query := `
+		SELECT to_regclass($1) IS NOT NULL
+    `
+	rows, err := sr.dbHandler.QueryContext(ctx, query, table)
+	if err != nil {
+		return false, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	var exists bool
+	for rows.Next() {
+		err = rows.Scan(&exists)
+		if err != nil {
+			return false, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return false, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return exists, nil
+}
+
+// GetAppliedMigrations returns the applied schema versions and when each was
+// applied.
+func (sr SchemaRepository) GetAppliedMigrations(ctx context.Context) (map[int]time.Time, *models.ResponseError) {
+	query := `
+		SELECT version, applied_at
+		FROM schema_migrations
+		ORDER BY version
+    `
+	rows, err := sr.dbHandler.QueryContext(ctx, query)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	applied := map[int]time.Time{}
+	var version int
+	var appliedAt time.Time
+	for rows.Next() {
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		applied[version] = appliedAt
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return applied, nil
+}
+
+// ApplyMigration runs script and records version as applied (up) or removes
+// it (down) in one transaction, so a failing script leaves no trace.
+func (sr SchemaRepository) ApplyMigration(ctx context.Context,
+	version int, script string, up bool) *models.ResponseError {
+	transaction, err := sr.dbHandler.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	if hasStatements(script) {
+		_, err = transaction.ExecContext(ctx, script)
+		if err != nil {
+			transaction.Rollback()
+			return &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	query := `
+		INSERT INTO schema_migrations(version)
+		VALUES ($1)
+		ON CONFLICT DO NOTHING
+    `
+	if !up {
+		query = `
+		DELETE FROM schema_migrations
+		WHERE version = $1
+    `
+	}
+	_, err = transaction.ExecContext(ctx, query, version)
+	if err != nil {
+		transaction.Rollback()
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	err = transaction.Commit()
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
+
+// hasStatements reports whether script contains anything besides comments.
+func hasStatements(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
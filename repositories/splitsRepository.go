@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+type SplitsRepository struct {
+	dbHandler *sql.DB
+}
+
+func NewSplitsRepository(dbHandler *sql.DB) *SplitsRepository {
+	return &SplitsRepository{
+		dbHandler: dbHandler,
+	}
+}
+
+// CreateSplits adds splits to a result in a single statement, so either all
+// of them are added or none.
+func (sr SplitsRepository) CreateSplits(ctx context.Context,
+	resultID string, splits []*models.Split) *models.ResponseError {
+	query := `
+		INSERT INTO result_splits(result_id, distance, elapsed_time)
+		SELECT $1, split.distance, split.elapsed_time
+		FROM unnest($2::integer[], $3::interval[])
+		    AS split(distance, elapsed_time)
+    `
+	distances := make([]int64, 0, len(splits))
+	elapsedTimes := make([]string, 0, len(splits))
+	for _, split := range splits {
+		distances = append(distances, int64(split.Distance))
+		elapsedTimes = append(elapsedTimes, split.ElapsedTime.String())
+	}
+	_, err := sr.dbHandler.ExecContext(ctx, query, resultID,
+		pq.Array(distances), pq.Array(elapsedTimes))
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
+
+// GetSplits returns the splits of a result ordered by distance.
+func (sr SplitsRepository) GetSplits(ctx context.Context,
+	resultID string) ([]*models.Split, *models.ResponseError) {
+	query := `
+		SELECT id, distance, elapsed_time
+		FROM result_splits
+		WHERE result_id = $1
+		ORDER BY distance
+    `
+	rows, err := sr.dbHandler.QueryContext(ctx, query, resultID)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	defer rows.Close()
+	splits := make([]*models.Split, 0)
+	var id string
+	var distance int
+	var elapsedTime models.RaceTime
+	for rows.Next() {
+		err = rows.Scan(&id, &distance, &elapsedTime)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+		splits = append(splits, &models.Split{
+			ID:          id,
+			ResultID:    resultID,
+			Distance:    distance,
+			ElapsedTime: elapsedTime,
+		})
+	}
+	if rows.Err() != nil {
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return splits, nil
+}
@@ -5,30 +5,10 @@ import (
 	"database/sql"
 )
 
-func BeginTransaction(runnersRepository *RunnersRepository,
-	resultsRepository *ResultsRepository) error {
-	ctx := context.Background()
-	transaction, err := resultsRepository.dbHandler.BeginTx(ctx, &sql.TxOptions{})
-	if err != nil {
-		return err
-	}
-	runnersRepository.transaction = transaction
-	resultsRepository.transaction = transaction
-	return nil
-}
-
-func RollbackTransaction(runnersRepository *RunnersRepository,
-	resultsRepository *ResultsRepository) error {
-	transaction := runnersRepository.transaction
-	runnersRepository.transaction = nil
-	resultsRepository.transaction = nil
-	return transaction.Rollback()
-}
-
-func CommitTransaction(runnersRepository *RunnersRepository,
-	resultsRepository *ResultsRepository) error {
-	transaction := runnersRepository.transaction
-	runnersRepository.transaction = transaction
-	resultsRepository.transaction = transaction
-	return transaction.Commit()
+// BeginTransaction begins a transaction for writing results and the bests of
+// runners. The repositories take it explicitly; the caller commits or rolls
+// it back.
+func BeginTransaction(ctx context.Context,
+	resultsRepository *ResultsRepository) (*sql.Tx, error) {
+	return resultsRepository.dbHandler.BeginTx(ctx, &sql.TxOptions{})
 }
@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"github.com/fentezi/runnerBook/models"
+	"github.com/lib/pq"
 	"net/http"
 )
 
@@ -14,14 +16,14 @@ func NewUsersRepository(dbHandler *sql.DB) *UsersRepository {
 	return &UsersRepository{dbHandler: dbHandler}
 }
 
-func (ur UsersRepository) LoginUser(username, password string) (string, *models.ResponseError) {
+func (ur UsersRepository) LoginUser(ctx context.Context, username, password string) (string, *models.ResponseError) {
 	query := `
 		SELECT id
 		FROM users
 		WHERE username = $1 AND
 		      user_password = crypt($2, user_password)
     `
-	rows, err := ur.dbHandler.Query(query, username, password)
+	rows, err := ur.dbHandler.QueryContext(ctx, query, username, password)
 	if err != nil {
 		return "", &models.ResponseError{
 			Message: err.Error(),
@@ -48,46 +50,63 @@ func (ur UsersRepository) LoginUser(username, password string) (string, *models.
 	return id, nil
 }
 
-func (ur UsersRepository) GetUserRole(accessToken string) (string, *models.ResponseError) {
+// GetUserByToken returns the user signed in with accessToken, with the
+// permissions of their role, or nil when there is none.
+func (ur UsersRepository) GetUserByToken(ctx context.Context, accessToken string) (*models.User, *models.ResponseError) {
+	return ur.getUser(ctx, "access_token", accessToken)
+}
+
+// GetUser returns the user named username, or nil when there is none.
+func (ur UsersRepository) GetUser(ctx context.Context, username string) (*models.User, *models.ResponseError) {
+	return ur.getUser(ctx, "username", username)
+}
+
+func (ur UsersRepository) getUser(ctx context.Context, column, value string) (*models.User, *models.ResponseError) {
 	query := `
-		SELECT user_role
+		SELECT users.id, users.username, users.user_role,
+		       COALESCE(array_agg(role_permissions.permission)
+		           FILTER (WHERE role_permissions.permission IS NOT NULL), '{}')
 		FROM users
-		WHERE access_token = $1
+		LEFT JOIN role_permissions ON role_permissions.role = users.user_role
+		WHERE users.` + column + ` = $1
+		GROUP BY users.id
     `
-	rows, err := ur.dbHandler.Query(query, accessToken)
+	rows, err := ur.dbHandler.QueryContext(ctx, query, value)
 	if err != nil {
-		return "", &models.ResponseError{
+		return nil, &models.ResponseError{
 			Message: err.Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
 	defer rows.Close()
-	var role string
+	var user *models.User
 	for rows.Next() {
-		err := rows.Scan(&role)
+		user = &models.User{}
+		err := rows.Scan(&user.ID, &user.Username, &user.Role,
+			pq.Array(&user.Permissions))
 		if err != nil {
-			return "", &models.ResponseError{
+			return nil, &models.ResponseError{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
 			}
 		}
 	}
 	if rows.Err() != nil {
-		return "", &models.ResponseError{
-			Message: err.Error(),
+		return nil, &models.ResponseError{
+			Message: rows.Err().Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
-	return role, nil
+	return user, nil
 }
 
-func (ur UsersRepository) SetAccessToken(accessToken string, id string) *models.ResponseError {
+func (ur UsersRepository) SetAccessToken(ctx context.Context, accessToken string, id string) *models.ResponseError {
 	query := `
 		UPDATE users
 		SET access_token = $1
 		WHERE id = $2
     `
-	_, err := ur.dbHandler.Exec(query, accessToken, id)
+	_, err := ur.dbHandler.ExecContext(ctx, query, accessToken, id)
 	if err != nil {
 		return &models.ResponseError{
 			Message: err.Error(),
@@ -97,18 +116,101 @@ func (ur UsersRepository) SetAccessToken(accessToken string, id string) *models.
 	return nil
 }
 
-func (ur UsersRepository) RemoveAccessToken(accessToken string) *models.ResponseError {
+func (ur UsersRepository) RemoveAccessToken(ctx context.Context, accessToken string) *models.ResponseError {
 	query := `
 		UPDATE users
 		SET access_token = ''
 		WHERE access_token = $1
     `
-	_, err := ur.dbHandler.Exec(query, accessToken)
+	_, err := ur.dbHandler.ExecContext(ctx, query, accessToken)
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return nil
+}
+
+// userExists is the conflict the unique constraint on usernames reports.
+const userExists = "User already exists"
+
+func (ur UsersRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, *models.ResponseError) {
+	query := `
+		INSERT INTO users(username, user_password, user_role)
+		VALUES ($1, crypt($2, gen_salt('bf')), $3)
+		RETURNING id
+    `
+	rows, err := ur.dbHandler.QueryContext(ctx, query, user.Username,
+		user.Password, user.Role)
+	if err != nil {
+		return nil, conflictError(err, userExists)
+	}
+	defer rows.Close()
+	var id string
+	for rows.Next() {
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, &models.ResponseError{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+			}
+		}
+	}
+	if rows.Err() != nil {
+		return nil, conflictError(rows.Err(), userExists)
+	}
+	return &models.User{
+		ID:       id,
+		Username: user.Username,
+		Role:     user.Role,
+	}, nil
+}
+
+// SetPassword changes the password of username and signs the user out.
+func (ur UsersRepository) SetPassword(ctx context.Context, username, password string) *models.ResponseError {
+	query := `
+		UPDATE users
+		SET user_password = crypt($1, gen_salt('bf')),
+		    access_token = ''
+		WHERE username = $2
+    `
+	res, err := ur.dbHandler.ExecContext(ctx, query, password, username)
+	return userUpdated(res, err)
+}
+
+// SetRole changes the role of username and signs the user out, so that the
+// new role applies from the next login.
+func (ur UsersRepository) SetRole(ctx context.Context, username, role string) *models.ResponseError {
+	query := `
+		UPDATE users
+		SET user_role = $1,
+		    access_token = ''
+		WHERE username = $2
+    `
+	res, err := ur.dbHandler.ExecContext(ctx, query, role, username)
+	return userUpdated(res, err)
+}
+
+func userUpdated(res sql.Result, err error) *models.ResponseError {
+	if err != nil {
+		return &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	rowsAffected, err := res.RowsAffected()
 	if err != nil {
 		return &models.ResponseError{
 			Message: err.Error(),
 			Status:  http.StatusInternalServerError,
 		}
 	}
+	if rowsAffected == 0 {
+		return &models.ResponseError{
+			Message: "User not found",
+			Status:  http.StatusNotFound,
+		}
+	}
 	return nil
 }
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/spf13/viper"
+	"log/slog"
+	"os"
+)
+
+// InitAgeGradingTable loads the age grading factors from
+// age_grading.factors_file, or uses the bundled factors when it is not set.
+func InitAgeGradingTable(config *viper.Viper) *racecalc.AgeGradingTable {
+	path := config.GetString("age_grading.factors_file")
+	if path == "" {
+		return racecalc.DefaultAgeGradingTable()
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		slog.Error("Error while opening age grading factors", "error", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	table, err := racecalc.LoadAgeGradingTable(file)
+	if err != nil {
+		slog.Error("Error while loading age grading factors", "path", path,
+			"error", err)
+		os.Exit(1)
+	}
+	return table
+}
@@ -2,23 +2,42 @@ package server
 
 import (
 	"database/sql"
+	"errors"
+	"github.com/XSAM/otelsql"
+	"github.com/fentezi/runnerBook/metrics"
 	_ "github.com/lib/pq"
 	"github.com/spf13/viper"
-	"log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
 )
 
 func InitDatabase(config *viper.Viper) *sql.DB {
 	connectionString := config.GetString("database.connecting_string")
+	password := config.GetString("database.password")
 	maxIdleConnections := config.GetInt("database.max_idle_connections")
 	maxOpenConnections := config.GetInt("database.max_open_connections")
 	connectionMaxLifetime := config.GetDuration("database.connection_max_lifetime")
 	driverName := config.GetString("database.driver_name")
 	if connectionString == "" {
-		log.Fatalf("Database connection string is missing")
+		slog.Error("Database connection string is missing")
+		os.Exit(1)
 	}
-	dbHandler, err := sql.Open(driverName, connectionString)
+	if password != "" {
+		var err error
+		connectionString, err = withPassword(connectionString, password)
+		if err != nil {
+			slog.Error("Error while initializing database", "error", err)
+			os.Exit(1)
+		}
+	}
+	dbHandler, err := otelsql.Open(driverName, connectionString,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
-		log.Fatalf("Error while initializing database: %v", err)
+		slog.Error("Error while initializing database", "error", err)
+		os.Exit(1)
 	}
 	dbHandler.SetMaxIdleConns(maxIdleConnections)
 	dbHandler.SetMaxOpenConns(maxOpenConnections)
@@ -26,7 +45,28 @@ func InitDatabase(config *viper.Viper) *sql.DB {
 	err = dbHandler.Ping()
 	if err != nil {
 		dbHandler.Close()
-		log.Fatalf("Error while validating database: %v", err)
+		slog.Error("Error while validating database", "error", err)
+		os.Exit(1)
 	}
+	metrics.RegisterDatabase(dbHandler, "runners_db",
+		maxOpenConnections, maxIdleConnections)
 	return dbHandler
 }
+
+// withPassword adds password to a pq connection string, either a URL or a
+// list of key=value pairs, so that the password can be kept out of
+// runners.toml and supplied through RUNNERS_DATABASE_PASSWORD(_FILE).
+func withPassword(connectionString, password string) (string, error) {
+	if strings.HasPrefix(connectionString, "postgres://") ||
+		strings.HasPrefix(connectionString, "postgresql://") {
+		connectionURL, err := url.Parse(connectionString)
+		if err != nil {
+			return "", errors.New("invalid database connection URL")
+		}
+		connectionURL.User = url.UserPassword(
+			connectionURL.User.Username(), password)
+		return connectionURL.String(), nil
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password)
+	return connectionString + " password='" + escaped + "'", nil
+}
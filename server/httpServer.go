@@ -1,21 +1,47 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"github.com/fentezi/runnerBook/controllers"
+	"github.com/fentezi/runnerBook/dbscripts"
+	"github.com/fentezi/runnerBook/logging"
+	"github.com/fentezi/runnerBook/metrics"
+	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/repositories"
 	"github.com/fentezi/runnerBook/services"
+	"github.com/fentezi/runnerBook/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
-	"log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"time"
 )
 
+// Build information, set at link time with
+// -ldflags "-X github.com/fentezi/runnerBook/server.GitCommit=... -X github.com/fentezi/runnerBook/server.BuildTime=..."
+var (
+	GitCommit = ""
+	BuildTime = ""
+)
+
+const defaultReadinessTimeout = 2 * time.Second
+
+// shutdownTimeout is how long requests in flight get to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 type HttpServer struct {
 	config            *viper.Viper
 	router            *gin.Engine
 	runnersController *controllers.RunnersController
 	resultController  *controllers.ResultsController
+	splitsController  *controllers.SplitsController
 	usersController   *controllers.UsersController
+	schemaRepository  *repositories.SchemaRepository
+	seasonScheduler   *services.SeasonScheduler
 }
 
 func InitHttpServer(config *viper.Viper,
@@ -23,36 +49,242 @@ func InitHttpServer(config *viper.Viper,
 	runnersRepository := repositories.NewRunnersRepository(dbHandler)
 	resultRepository := repositories.NewResultsRepository(dbHandler)
 	usersRepository := repositories.NewUsersRepository(dbHandler)
-	runnersService := services.NewRunnersService(
-		runnersRepository, resultRepository)
-	resultsService := services.NewResultsService(
-		resultRepository, runnersRepository)
-	usersService := services.NewUsersService(usersRepository)
-	runnersController := controllers.NewRunnersController(runnersService, usersService)
-	resultsController := controllers.NewResultsController(resultsService, usersService)
+	splitsRepository := repositories.NewSplitsRepository(dbHandler)
+	schemaRepository := repositories.NewSchemaRepository(dbHandler)
+	ratingsRepository := repositories.NewRatingsRepository(dbHandler)
+	recordsRepository := repositories.NewRecordsRepository(dbHandler)
+	countriesRepository := repositories.NewCountriesRepository(dbHandler)
+	clubsRepository := repositories.NewClubsRepository(dbHandler)
+	coachesRepository := repositories.NewCoachesRepository(dbHandler)
+	rolesRepository := repositories.NewRolesRepository(dbHandler)
+	seasonCalendar := InitSeasonCalendar(config)
+	ageGrading := InitAgeGradingTable(config)
+	countriesService := services.NewCountriesService(countriesRepository)
+	coachesService := services.NewCoachesService(coachesRepository,
+		usersRepository, runnersRepository)
+	rankingsService := services.NewRankingsService(ratingsRepository,
+		countriesService)
+	runnersService := services.NewRunnersService(runnersRepository,
+		resultRepository, seasonCalendar, ageGrading, countriesService)
+	resultsService := services.NewResultsService(resultRepository,
+		runnersRepository, recordsRepository, seasonCalendar, ageGrading,
+		rankingsService, coachesService)
+	recordsService := services.NewRecordsService(recordsRepository,
+		countriesService)
+	clubsService := services.NewClubsService(clubsRepository,
+		runnersRepository, countriesService)
+	usersService := services.NewUsersService(usersRepository, rolesRepository)
+	rolesService := services.NewRolesService(rolesRepository)
+	splitsService := services.NewSplitsService(splitsRepository,
+		resultRepository, coachesService)
+	performanceService := services.NewPerformanceService(runnersRepository,
+		resultRepository, config.GetInt("performance.window_days"))
+	authorizer := controllers.NewAuthorizer(usersService)
+	runnersController := controllers.NewRunnersController(runnersService)
+	resultsController := controllers.NewResultsController(resultsService)
 	usersController := controllers.NewUsersController(usersService)
-	router := gin.Default()
-	router.POST("/runner", runnersController.CreateRunner)
-	router.PUT("/runner", runnersController.UpdateRunner)
-	router.DELETE("/runner/:id", runnersController.DeleteRunner)
-	router.GET("/runner/:id", runnersController.GetRunner)
-	router.POST("/login", usersController.Login)
-	router.POST("/logout", usersController.Logout)
-	router.GET("/runner", runnersController.GetRunnersBatch)
-	router.POST("/result", resultsController.CreateResult)
-	router.DELETE("/result/:id", resultsController.DeleteResult)
-	return HttpServer{
+	splitsController := controllers.NewSplitsController(splitsService)
+	performanceController := controllers.NewPerformanceController(
+		performanceService)
+	rankingsController := controllers.NewRankingsController(rankingsService)
+	recordsController := controllers.NewRecordsController(recordsService)
+	countriesController := controllers.NewCountriesController(countriesService)
+	clubsController := controllers.NewClubsController(clubsService)
+	coachesController := controllers.NewCoachesController(coachesService)
+	rolesController := controllers.NewRolesController(rolesService)
+	httpServer := HttpServer{
 		config:            config,
-		router:            router,
 		runnersController: runnersController,
 		resultController:  resultsController,
+		splitsController:  splitsController,
 		usersController:   usersController,
+		schemaRepository:  schemaRepository,
+	}
+	if config.GetBool("season.rollover") {
+		httpServer.seasonScheduler = services.NewSeasonScheduler(
+			runnersService, seasonCalendar)
 	}
+	router := gin.New()
+	router.Use(logging.Middleware(), logging.Recovery())
+	router.Use(cors(), rateLimit())
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	router.Use(metrics.Middleware())
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/healthz", httpServer.Liveness)
+	router.GET("/readyz", httpServer.Readiness)
+	router.GET("/version", httpServer.Version)
+	router.POST("/login", usersController.Login)
+	router.POST("/logout", usersController.Logout)
+	read := authorizer.Require(models.PermissionRunnerRead)
+	runnerWrite := authorizer.Require(models.PermissionRunnerWrite)
+	router.POST("/runner", runnerWrite, runnersController.CreateRunner)
+	router.PUT("/runner", runnerWrite, runnersController.UpdateRunner)
+	router.DELETE("/runner/:id", runnerWrite, runnersController.DeleteRunner)
+	router.GET("/runner/:id", read, runnersController.GetRunner)
+	router.GET("/runner/:id/predictions", read,
+		performanceController.GetPredictions)
+	router.GET("/runner/:id/paces", read, performanceController.GetPaces)
+	router.GET("/runner/:id/stats", read, performanceController.GetStats)
+	router.GET("/runner/:id/memberships", read,
+		clubsController.GetRunnerMemberships)
+	router.GET("/runner", read, runnersController.GetRunnersBatch)
+	router.GET("/compare", read, runnersController.CompareRunners)
+	router.GET("/rankings", read, rankingsController.GetRankings)
+	router.GET("/records", read, recordsController.GetRecords)
+	router.GET("/countries", read, countriesController.GetCountries)
+	clubManage := authorizer.Require(models.PermissionClubManage)
+	router.POST("/club", clubManage, clubsController.CreateClub)
+	router.PUT("/club", clubManage, clubsController.UpdateClub)
+	router.DELETE("/club/:id", clubManage, clubsController.DeleteClub)
+	router.GET("/club/:id", read, clubsController.GetClub)
+	router.GET("/club/:id/leaderboard", read, clubsController.GetClubBests)
+	router.GET("/club", read, clubsController.GetClubs)
+	router.POST("/membership", clubManage, clubsController.CreateMembership)
+	router.PUT("/membership", clubManage, clubsController.UpdateMembership)
+	router.DELETE("/membership/:id", clubManage,
+		clubsController.DeleteMembership)
+	router.GET("/teams", read, clubsController.GetTeamResults)
+	resultWrite := authorizer.Require(models.PermissionResultWrite)
+	resultAnnotate := authorizer.Require(models.PermissionResultAnnotate)
+	router.POST("/result", resultWrite, resultsController.CreateResult)
+	router.DELETE("/result/:id",
+		authorizer.Require(models.PermissionResultDelete),
+		resultsController.DeleteResult)
+	router.POST("/result/:id/splits", resultWrite, splitsController.AddSplits)
+	router.GET("/result/:id/splits", read, splitsController.GetSplits)
+	router.POST("/result/:id/notes", resultAnnotate,
+		resultsController.AddResultNote)
+	router.GET("/result/:id/notes", resultAnnotate,
+		resultsController.GetResultNotes)
+	coachManage := authorizer.Require(models.PermissionCoachManage)
+	router.GET("/coach/:username/athletes", read, coachesController.GetAthletes)
+	router.POST("/coach/:username/athletes/:runner_id", coachManage,
+		coachesController.AssignAthlete)
+	router.DELETE("/coach/:username/athletes/:runner_id", coachManage,
+		coachesController.UnassignAthlete)
+	userManage := authorizer.Require(models.PermissionUserManage)
+	router.GET("/permissions", userManage, rolesController.GetPermissions)
+	router.GET("/role", userManage, rolesController.GetRoles)
+	router.POST("/role", userManage, rolesController.CreateRole)
+	router.PUT("/role", userManage, rolesController.UpdateRole)
+	router.DELETE("/role/:name", userManage, rolesController.DeleteRole)
+	seasonManage := authorizer.Require(models.PermissionSeasonManage)
+	router.POST("/admin/recompute-bests", seasonManage,
+		runnersController.RecomputeBests)
+	router.POST("/admin/season/rollover", seasonManage,
+		runnersController.RolloverSeason)
+	router.POST("/admin/rankings/recompute", seasonManage,
+		rankingsController.RecomputeRatings)
+	httpServer.router = router
+	return httpServer
 }
 
-func (h *HttpServer) Start() {
-	err := h.router.Run(h.config.GetString("http.server_address"))
+// Start serves requests until ctx is cancelled. It then stops accepting
+// connections and returns once the requests in flight and the season
+// scheduler have finished.
+func (h *HttpServer) Start(ctx context.Context) {
+	schedulerDone := make(chan struct{})
+	if h.seasonScheduler != nil {
+		go func() {
+			defer close(schedulerDone)
+			h.seasonScheduler.Run(ctx)
+		}()
+	} else {
+		close(schedulerDone)
+	}
+	server := &http.Server{
+		Addr:    h.config.GetString("http.server_address"),
+		Handler: h.router,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		slog.Error("Error while starting HTTP server", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	slog.Info("Shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(),
+		shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
 	if err != nil {
-		log.Fatalf("Error while statring HTTP server: %v", err)
+		slog.Error("Error while shutting down HTTP server", "error", err)
+	}
+	<-schedulerDone
+}
+
+// Liveness reports that the process is up and serving requests. It does not
+// touch the database, so a database outage does not get the process restarted.
+func (h HttpServer) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness reports whether the server can handle traffic: the database must
+// answer a ping within http.readiness_timeout and the schema must be migrated
+// to the version this build expects.
+func (h HttpServer) Readiness(c *gin.Context) {
+	timeout := h.config.GetDuration("http.readiness_timeout")
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	response := gin.H{
+		"status":                  "ready",
+		"database":                "ok",
+		"expected_schema_version": dbscripts.LatestVersion(),
+	}
+	responseErr := h.schemaRepository.Ping(ctx)
+	if responseErr != nil {
+		response["status"] = "unavailable"
+		response["database"] = responseErr.Message
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	version, responseErr := h.schemaRepository.GetSchemaVersion(ctx)
+	if responseErr != nil {
+		response["status"] = "unavailable"
+		response["migrations"] = responseErr.Message
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	response["schema_version"] = version
+	if version < dbscripts.LatestVersion() {
+		response["status"] = "unavailable"
+		response["migrations"] = "pending"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	response["migrations"] = "up to date"
+	c.JSON(http.StatusOK, response)
+}
+
+// Version reports the build the server was compiled from.
+func (h HttpServer) Version(c *gin.Context) {
+	gitCommit, buildTime := GitCommit, BuildTime
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && gitCommit == "":
+				gitCommit = setting.Value
+			case setting.Key == "vcs.time" && buildTime == "":
+				buildTime = setting.Value
+			}
+		}
+	}
+	if gitCommit == "" {
+		gitCommit = "unknown"
+	}
+	if buildTime == "" {
+		buildTime = "unknown"
 	}
+	c.JSON(http.StatusOK, gin.H{
+		"git_commit":     gitCommit,
+		"build_time":     buildTime,
+		"schema_version": dbscripts.LatestVersion(),
+	})
 }
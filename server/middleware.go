@@ -0,0 +1,118 @@
+package server
+
+import (
+	"github.com/fentezi/runnerBook/config"
+	"github.com/fentezi/runnerBook/logging"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientIdleTimeout is how long a client's rate limiter is kept after its
+// last request.
+const clientIdleTimeout = 10 * time.Minute
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter limits requests per client IP. The limits are read from the
+// runtime settings on every request, so a reload applies immediately.
+type rateLimiter struct {
+	mutex     sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+func rateLimit() gin.HandlerFunc {
+	limiter := &rateLimiter{clients: map[string]*clientLimiter{}}
+	return limiter.handle
+}
+
+func (rl *rateLimiter) handle(c *gin.Context) {
+	runtime := config.Current()
+	if runtime.RequestsPerSecond <= 0 {
+		c.Next()
+		return
+	}
+	burst := runtime.Burst
+	if burst <= 0 {
+		burst = int(runtime.RequestsPerSecond) + 1
+	}
+	if !rl.allow(c.ClientIP(), rate.Limit(runtime.RequestsPerSecond), burst) {
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, &models.ResponseError{
+			Message:   "Too many requests",
+			Status:    http.StatusTooManyRequests,
+			RequestID: logging.RequestID(c.Request.Context()),
+		})
+		return
+	}
+	c.Next()
+}
+
+func (rl *rateLimiter) allow(clientIP string, limit rate.Limit, burst int) bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(rl.lastSweep) > clientIdleTimeout {
+		for ip, client := range rl.clients {
+			if now.Sub(client.lastSeen) > clientIdleTimeout {
+				delete(rl.clients, ip)
+			}
+		}
+		rl.lastSweep = now
+	}
+	client, ok := rl.clients[clientIP]
+	if !ok {
+		client = &clientLimiter{limiter: rate.NewLimiter(limit, burst)}
+		rl.clients[clientIP] = client
+	}
+	if client.limiter.Limit() != limit {
+		client.limiter.SetLimitAt(now, limit)
+	}
+	if client.limiter.Burst() != burst {
+		client.limiter.SetBurstAt(now, burst)
+	}
+	client.lastSeen = now
+	return client.limiter.AllowN(now, 1)
+}
+
+// cors allows browsers on the origins in cors.allowed_origins ("*" for any)
+// to call the API and answers their preflight requests.
+func cors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		allowedOrigins := config.Current().CORSAllowedOrigins
+		if !slices.Contains(allowedOrigins, "*") &&
+			!slices.Contains(allowedOrigins, origin) {
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Vary", "Origin")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID")
+		if c.Request.Method == http.MethodOptions &&
+			c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", strings.Join([]string{
+				http.MethodGet, http.MethodPost, http.MethodPut,
+				http.MethodDelete}, ", "))
+			c.Header("Access-Control-Allow-Headers",
+				"Authorization, Content-Type, Token, X-Request-ID, traceparent, tracestate")
+			c.Header("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/fentezi/runnerBook/services"
+	"github.com/spf13/viper"
+	"log/slog"
+	"os"
+)
+
+// InitSeasonCalendar creates the season calendar from season.timezone and
+// season.start.
+func InitSeasonCalendar(config *viper.Viper) *services.SeasonCalendar {
+	seasonCalendar, err := services.NewSeasonCalendar(
+		config.GetString("season.timezone"), config.GetString("season.start"))
+	if err != nil {
+		slog.Error("Error while initializing season calendar", "error", err)
+		os.Exit(1)
+	}
+	return seasonCalendar
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"sort"
+	"time"
+)
+
+// Rankings of the runners listing.
+const (
+	RankingTime      = "time"
+	RankingAgeGraded = "age_graded"
+)
+
+// leaderboardSize is the number of runners on a leaderboard.
+const leaderboardSize = 10
+
+// gradeResult sets the age grade of a finished result of runner, when the
+// runner's category and date of birth are known and the table covers the
+// distance.
+func gradeResult(ageGrading *racecalc.AgeGradingTable, runner *models.Runner,
+	result *models.Result) {
+	if result.Status != models.StatusFinished || result.RaceResult <= 0 ||
+		runner.Category == "" || runner.DateOfBirth == "" {
+		return
+	}
+	dateOfBirth, err := time.Parse(dateLayout, runner.DateOfBirth)
+	if err != nil {
+		return
+	}
+	age := models.AgeOn(dateOfBirth, resultDate(result))
+	grade, ok := ageGrading.AgeGrade(runner.Category, age, result.Distance,
+		result.RaceResult.Duration())
+	if ok {
+		result.AgeGrade = grade
+	}
+}
+
+// resultDate is the race date of a result, or July 1st of its year when the
+// date is unknown.
+func resultDate(result *models.Result) time.Time {
+	date, err := time.Parse(dateLayout, result.RaceDate)
+	if err != nil {
+		return time.Date(result.Year, time.July, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return date
+}
+
+// rankByAgeGrade keeps the best age-graded result of every runner, with the
+// runner's age group at that race, and returns the leaderboardSize runners
+// with the highest age grades.
+func rankByAgeGrade(ageGrading *racecalc.AgeGradingTable,
+	runners []*models.Runner) []*models.Runner {
+	ranked := make([]*models.Runner, 0, len(runners))
+	for _, runner := range runners {
+		var best *models.Result
+		for _, result := range runner.Results {
+			gradeResult(ageGrading, runner, result)
+			if result.AgeGrade > 0 && (best == nil || result.AgeGrade > best.AgeGrade) {
+				best = result
+			}
+		}
+		if best == nil {
+			continue
+		}
+		dateOfBirth, _ := time.Parse(dateLayout, runner.DateOfBirth)
+		runner.AgeGroup = models.AgeGroup(runner.Category,
+			models.AgeOn(dateOfBirth, resultDate(best)))
+		runner.Results = []*models.Result{best}
+		ranked = append(ranked, runner)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Results[0].AgeGrade > ranked[j].Results[0].AgeGrade
+	})
+	if len(ranked) > leaderboardSize {
+		ranked = ranked[:leaderboardSize]
+	}
+	return ranked
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestRankByAgeGrade(t *testing.T) {
+	result := func(raceResult string, distance int, status string) *models.Result {
+		parsed, _ := models.ParseRaceTime(raceResult)
+		return &models.Result{
+			RaceResult: parsed,
+			Distance:   distance,
+			Status:     status,
+			RaceDate:   "2023-06-01",
+			Year:       2023,
+		}
+	}
+	runners := []*models.Runner{
+		{ID: "1", Category: "M", DateOfBirth: "1990-01-01", Results: []*models.Result{
+			result("02:30:00", models.MarathonDistance, models.StatusFinished),
+			result("00:17:00", 5000, models.StatusFinished),
+		}},
+		{ID: "2", Category: "W", DateOfBirth: "1970-03-15", Results: []*models.Result{
+			result("00:40:00", 10000, models.StatusFinished),
+		}},
+		{ID: "3", Category: "M", DateOfBirth: "1980-01-01", Results: []*models.Result{
+			result("02:10:00", models.MarathonDistance, models.StatusDidNotFinish),
+		}},
+		{ID: "4", Results: []*models.Result{
+			result("02:05:00", models.MarathonDistance, models.StatusFinished),
+		}},
+	}
+	ranked := rankByAgeGrade(racecalc.DefaultAgeGradingTable(), runners)
+	assert.Equal(t, len(ranked), 2)
+	for i, runner := range ranked {
+		assert.Equal(t, len(runner.Results), 1)
+		if i > 0 {
+			assert.Equal(t, runner.Results[0].AgeGrade <= ranked[i-1].Results[0].AgeGrade, true)
+		}
+	}
+	for _, runner := range ranked {
+		if runner.ID == "1" {
+			assert.Equal(t, runner.AgeGroup, "MSEN")
+			marathon, _ := racecalc.DefaultAgeGradingTable().AgeGrade("M", 33,
+				models.MarathonDistance, 150*time.Minute)
+			fiveK, _ := racecalc.DefaultAgeGradingTable().AgeGrade("M", 33, 5000,
+				17*time.Minute)
+			best := max(marathon, fiveK)
+			assert.Equal(t, runner.Results[0].AgeGrade, best)
+		}
+		if runner.ID == "2" {
+			assert.Equal(t, runner.AgeGroup, "W50")
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// defaultTeamSize is the number of finishers scoring for a team when none is
+// given.
+const defaultTeamSize = 3
+
+type ClubsService struct {
+	clubsRepository   *repositories.ClubsRepository
+	runnersRepository *repositories.RunnersRepository
+	countriesService  *CountriesService
+}
+
+func NewClubsService(clubsRepository *repositories.ClubsRepository,
+	runnersRepository *repositories.RunnersRepository,
+	countriesService *CountriesService) *ClubsService {
+	return &ClubsService{
+		clubsRepository:   clubsRepository,
+		runnersRepository: runnersRepository,
+		countriesService:  countriesService,
+	}
+}
+
+func (cs ClubsService) CreateClub(ctx context.Context,
+	club *models.Club) (*models.Club, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.CreateClub")
+	defer span.End()
+	responseErr := cs.validateClub(ctx, club)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return cs.clubsRepository.CreateClub(ctx, club)
+}
+
+func (cs ClubsService) UpdateClub(ctx context.Context,
+	club *models.Club) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.UpdateClub")
+	defer span.End()
+	responseErr := validateClubID(club.ID)
+	if responseErr != nil {
+		return responseErr
+	}
+	responseErr = cs.validateClub(ctx, club)
+	if responseErr != nil {
+		return responseErr
+	}
+	return cs.clubsRepository.UpdateClub(ctx, club)
+}
+
+func (cs ClubsService) DeleteClub(ctx context.Context,
+	clubID string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.DeleteClub")
+	defer span.End()
+	responseErr := validateClubID(clubID)
+	if responseErr != nil {
+		return responseErr
+	}
+	return cs.clubsRepository.DeleteClub(ctx, clubID)
+}
+
+// GetClub returns a club with its memberships, past and present.
+func (cs ClubsService) GetClub(ctx context.Context,
+	clubID string) (*models.Club, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.GetClub")
+	defer span.End()
+	club, responseErr := cs.getClub(ctx, clubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	club.Memberships, responseErr = cs.clubsRepository.GetClubMemberships(ctx,
+		clubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return club, nil
+}
+
+func (cs ClubsService) GetClubs(ctx context.Context) ([]*models.Club, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.GetClubs")
+	defer span.End()
+	return cs.clubsRepository.GetClubs(ctx)
+}
+
+// GetClubBests returns the club leaderboard: the best result of every runner
+// over distance, the marathon by default, set while in the club, in year or
+// in any year when year is empty.
+func (cs ClubsService) GetClubBests(ctx context.Context, clubID, distance,
+	year string) ([]*models.ClubBest, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.GetClubBests")
+	defer span.End()
+	raceDistance, responseErr := parseDistance(distance)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	raceYear := 0
+	if year != "" {
+		var err error
+		raceYear, err = strconv.Atoi(year)
+		if err != nil || raceYear <= 0 {
+			return nil, &models.ResponseError{
+				Message: "Invalid year",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	_, responseErr = cs.getClub(ctx, clubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return cs.clubsRepository.GetClubBests(ctx, clubID, raceDistance, raceYear)
+}
+
+func (cs ClubsService) CreateMembership(ctx context.Context,
+	membership *models.Membership) (*models.Membership, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.CreateMembership")
+	defer span.End()
+	responseErr := validateClubID(membership.ClubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = validateRunnerID(membership.RunnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = validateMembershipDates(membership)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	_, responseErr = cs.getClub(ctx, membership.ClubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	runner, responseErr := cs.runnersRepository.GetRunner(ctx, membership.RunnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if runner.ID == "" {
+		return nil, &models.ResponseError{
+			Message: "Runner not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	responseErr = cs.checkOverlap(ctx, membership)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return cs.clubsRepository.CreateMembership(ctx, membership)
+}
+
+// UpdateMembership updates the dates of a membership; its club and runner
+// stay.
+func (cs ClubsService) UpdateMembership(ctx context.Context,
+	membership *models.Membership) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.UpdateMembership")
+	defer span.End()
+	responseErr := validateMembershipID(membership.ID)
+	if responseErr != nil {
+		return responseErr
+	}
+	responseErr = validateMembershipDates(membership)
+	if responseErr != nil {
+		return responseErr
+	}
+	current, responseErr := cs.clubsRepository.GetMembership(ctx, membership.ID)
+	if responseErr != nil {
+		return responseErr
+	}
+	if current == nil {
+		return &models.ResponseError{
+			Message: "Membership not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	membership.ClubID = current.ClubID
+	membership.RunnerID = current.RunnerID
+	responseErr = cs.checkOverlap(ctx, membership)
+	if responseErr != nil {
+		return responseErr
+	}
+	return cs.clubsRepository.UpdateMembership(ctx, membership)
+}
+
+func (cs ClubsService) DeleteMembership(ctx context.Context,
+	membershipID string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.DeleteMembership")
+	defer span.End()
+	responseErr := validateMembershipID(membershipID)
+	if responseErr != nil {
+		return responseErr
+	}
+	return cs.clubsRepository.DeleteMembership(ctx, membershipID)
+}
+
+// GetRunnerMemberships returns the club memberships of a runner over their
+// career.
+func (cs ClubsService) GetRunnerMemberships(ctx context.Context,
+	runnerID string) ([]*models.Membership, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.GetRunnerMemberships")
+	defer span.End()
+	responseErr := validateRunnerID(runnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return cs.clubsRepository.GetRunnerMemberships(ctx, runnerID)
+}
+
+// TeamResultsQuery holds the parameters of the team results of a race. Size,
+// the number of scoring finishers, defaults to defaultTeamSize, Distance to
+// the marathon and Scoring to models.ScoringTime.
+type TeamResultsQuery struct {
+	Race     string
+	Year     string
+	Distance string
+	Size     string
+	Scoring  string
+}
+
+// GetTeamResults scores the clubs in a race by their first finishers,
+// attributing runners to the club they belonged to on the race date.
+func (cs ClubsService) GetTeamResults(ctx context.Context,
+	query TeamResultsQuery) (*models.TeamResults, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ClubsService.GetTeamResults")
+	defer span.End()
+	if query.Race == "" {
+		return nil, &models.ResponseError{
+			Message: "Invalid race",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	year, err := strconv.Atoi(query.Year)
+	if err != nil || year <= 0 || year > time.Now().Year() {
+		return nil, &models.ResponseError{
+			Message: "Invalid year",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	distance, responseErr := parseDistance(query.Distance)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	size := defaultTeamSize
+	if query.Size != "" {
+		size, err = strconv.Atoi(query.Size)
+		if err != nil || size <= 0 {
+			return nil, &models.ResponseError{
+				Message: "Invalid team size",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	scoring := query.Scoring
+	switch scoring {
+	case "":
+		scoring = models.ScoringTime
+	case models.ScoringTime, models.ScoringPlaces:
+	default:
+		return nil, &models.ResponseError{
+			Message: "Invalid scoring",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	results, responseErr := cs.clubsRepository.GetRaceResults(ctx, query.Race,
+		year, distance)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if len(results) == 0 {
+		return nil, &models.ResponseError{
+			Message: "Race not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	return &models.TeamResults{
+		Race:     results[0].Location,
+		Year:     year,
+		Distance: distance,
+		Size:     size,
+		Scoring:  scoring,
+		Teams:    scoreTeams(results, size, scoring),
+	}, nil
+}
+
+func (cs ClubsService) getClub(ctx context.Context,
+	clubID string) (*models.Club, *models.ResponseError) {
+	responseErr := validateClubID(clubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	club, responseErr := cs.clubsRepository.GetClub(ctx, clubID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if club == nil {
+		return nil, &models.ResponseError{
+			Message: "Club not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	return club, nil
+}
+
+func (cs ClubsService) validateClub(ctx context.Context,
+	club *models.Club) *models.ResponseError {
+	if club.Name == "" {
+		return &models.ResponseError{
+			Message: "Invalid name",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	var responseErr *models.ResponseError
+	club.Country, responseErr = cs.countriesService.NormalizeCountry(ctx,
+		club.Country)
+	return responseErr
+}
+
+// checkOverlap rejects a membership overlapping another membership of the
+// runner, who belongs to one club at a time.
+func (cs ClubsService) checkOverlap(ctx context.Context,
+	membership *models.Membership) *models.ResponseError {
+	memberships, responseErr := cs.clubsRepository.GetRunnerMemberships(ctx,
+		membership.RunnerID)
+	if responseErr != nil {
+		return responseErr
+	}
+	for _, other := range memberships {
+		if other.ID != membership.ID && membershipsOverlap(membership, other) {
+			return &models.ResponseError{
+				Message: "Membership overlaps another membership",
+				Status:  http.StatusConflict,
+			}
+		}
+	}
+	return nil
+}
+
+// membershipsOverlap reports whether two memberships share a day. Dates are
+// "YYYY-MM-DD", so they compare as strings; an open membership never ends.
+func membershipsOverlap(a, b *models.Membership) bool {
+	endsAfter := func(m *models.Membership, date string) bool {
+		return m.LeftOn == "" || m.LeftOn > date
+	}
+	return endsAfter(a, b.JoinedOn) && endsAfter(b, a.JoinedOn)
+}
+
+// scoreTeams ranks the clubs of a race with at least size finishers by the
+// sum of the times or places of their first size finishers, lowest first.
+// Places are overall places in the race. Teams on the same score are split
+// by the place of their last scorer.
+func scoreTeams(results []*models.Result, size int,
+	scoring string) []*models.TeamScore {
+	race, places := finishers(results)
+	teams := map[string]*models.TeamScore{}
+	lastPlaces := map[*models.TeamScore]int{}
+	order := make([]*models.TeamScore, 0)
+	for i, result := range race {
+		if result.ClubID == "" {
+			continue
+		}
+		team := teams[result.ClubID]
+		if team == nil {
+			team = &models.TeamScore{ClubID: result.ClubID, Club: result.Club}
+			teams[result.ClubID] = team
+			order = append(order, team)
+		}
+		if len(team.Scorers) == size {
+			continue
+		}
+		team.Scorers = append(team.Scorers, result)
+		if scoring == models.ScoringPlaces {
+			team.Points += places[i]
+		} else {
+			team.Time += result.RaceResult
+		}
+		lastPlaces[team] = places[i]
+	}
+	scored := make([]*models.TeamScore, 0, len(order))
+	for _, team := range order {
+		if len(team.Scorers) == size {
+			scored = append(scored, team)
+		}
+	}
+	score := func(team *models.TeamScore) int64 {
+		if scoring == models.ScoringPlaces {
+			return int64(team.Points)
+		}
+		return int64(team.Time)
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if score(scored[i]) != score(scored[j]) {
+			return score(scored[i]) < score(scored[j])
+		}
+		return lastPlaces[scored[i]] < lastPlaces[scored[j]]
+	})
+	for i, team := range scored {
+		team.Rank = i + 1
+		if i > 0 && score(team) == score(scored[i-1]) &&
+			lastPlaces[team] == lastPlaces[scored[i-1]] {
+			team.Rank = scored[i-1].Rank
+		}
+	}
+	return scored
+}
+
+// parseDistance parses a distance in metres, the marathon when empty.
+func parseDistance(distance string) (int, *models.ResponseError) {
+	if distance == "" {
+		return models.MarathonDistance, nil
+	}
+	metres, err := strconv.Atoi(distance)
+	if err != nil || metres <= 0 {
+		return 0, &models.ResponseError{
+			Message: "Invalid distance",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return metres, nil
+}
+
+func validateMembershipDates(membership *models.Membership) *models.ResponseError {
+	joinedOn, err := time.Parse(dateLayout, membership.JoinedOn)
+	if err != nil {
+		return &models.ResponseError{
+			Message: "Invalid joined date",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if membership.LeftOn != "" {
+		leftOn, err := time.Parse(dateLayout, membership.LeftOn)
+		if err != nil || !leftOn.After(joinedOn) {
+			return &models.ResponseError{
+				Message: "Invalid left date",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	return nil
+}
+
+func validateClubID(clubID string) *models.ResponseError {
+	if clubID == "" {
+		return &models.ResponseError{
+			Message: "Invalid club ID",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+func validateMembershipID(membershipID string) *models.ResponseError {
+	if membershipID == "" {
+		return &models.ResponseError{
+			Message: "Invalid membership ID",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestMembershipsOverlap(t *testing.T) {
+	membership := func(joinedOn, leftOn string) *models.Membership {
+		return &models.Membership{JoinedOn: joinedOn, LeftOn: leftOn}
+	}
+	tests := []struct {
+		name string
+		a, b *models.Membership
+		want bool
+	}{
+		{"Before", membership("2020-01-01", "2021-01-01"), membership("2021-01-01", "2022-01-01"), false},
+		{"After", membership("2022-01-01", ""), membership("2021-01-01", "2022-01-01"), false},
+		{"Overlapping", membership("2020-01-01", "2021-06-01"), membership("2021-01-01", "2022-01-01"), true},
+		{"Within", membership("2020-01-01", ""), membership("2021-01-01", "2022-01-01"), true},
+		{"Both_Open", membership("2020-01-01", ""), membership("2023-01-01", ""), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, membershipsOverlap(test.a, test.b), test.want)
+			assert.Equal(t, membershipsOverlap(test.b, test.a), test.want)
+		})
+	}
+}
+
+func TestScoreTeams(t *testing.T) {
+	result := func(runnerID, clubID string, minutes int) *models.Result {
+		return &models.Result{
+			RunnerID:   runnerID,
+			ClubID:     clubID,
+			Club:       clubID,
+			RaceResult: models.RaceTime(time.Duration(minutes) * time.Minute),
+		}
+	}
+	type team struct {
+		rank    int
+		club    string
+		time    models.RaceTime
+		points  int
+		scorers int
+	}
+	teams := func(scores []*models.TeamScore) []team {
+		got := []team{}
+		for _, score := range scores {
+			got = append(got, team{score.Rank, score.ClubID, score.Time,
+				score.Points, len(score.Scorers)})
+		}
+		return got
+	}
+	minutes := func(minutes int) models.RaceTime {
+		return models.RaceTime(time.Duration(minutes) * time.Minute)
+	}
+	race := []*models.Result{
+		result("a", "x", 100), result("b", "", 101), result("c", "y", 102),
+		result("d", "x", 103), result("e", "y", 104), result("f", "x", 105),
+		result("g", "z", 107),
+	}
+	tests := []struct {
+		name    string
+		race    []*models.Result
+		size    int
+		scoring string
+		want    []team
+	}{
+		{
+			name:    "By_Time",
+			race:    race,
+			size:    2,
+			scoring: models.ScoringTime,
+			want:    []team{{1, "x", minutes(203), 0, 2}, {2, "y", minutes(206), 0, 2}},
+		},
+		{
+			// Places count every finisher, including b, who has no club.
+			name:    "By_Places",
+			race:    race,
+			size:    2,
+			scoring: models.ScoringPlaces,
+			want:    []team{{1, "x", 0, 5, 2}, {2, "y", 0, 8, 2}},
+		},
+		{
+			name:    "Incomplete_Teams",
+			race:    race,
+			size:    3,
+			scoring: models.ScoringTime,
+			want:    []team{{1, "x", minutes(308), 0, 3}},
+		},
+		{
+			// Both teams score 5; y's last scorer placed third, x's fourth.
+			name: "Tie_Split_By_Last_Scorer",
+			race: []*models.Result{result("a", "x", 100), result("b", "y", 101),
+				result("c", "y", 102), result("d", "x", 103)},
+			size:    2,
+			scoring: models.ScoringPlaces,
+			want:    []team{{1, "y", 0, 5, 2}, {2, "x", 0, 5, 2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, teams(scoreTeams(test.race, test.size, test.scoring)), test.want)
+		})
+	}
+}
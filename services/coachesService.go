@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+)
+
+type CoachesService struct {
+	coachesRepository *repositories.CoachesRepository
+	usersRepository   *repositories.UsersRepository
+	runnersRepository *repositories.RunnersRepository
+}
+
+func NewCoachesService(coachesRepository *repositories.CoachesRepository,
+	usersRepository *repositories.UsersRepository,
+	runnersRepository *repositories.RunnersRepository) *CoachesService {
+	return &CoachesService{
+		coachesRepository: coachesRepository,
+		usersRepository:   usersRepository,
+		runnersRepository: runnersRepository,
+	}
+}
+
+// AssignAthlete assigns a runner to the coach named username.
+func (cs CoachesService) AssignAthlete(ctx context.Context, username,
+	runnerID string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "CoachesService.AssignAthlete")
+	defer span.End()
+	coach, responseErr := cs.getCoach(ctx, username)
+	if responseErr != nil {
+		return responseErr
+	}
+	responseErr = validateRunnerID(runnerID)
+	if responseErr != nil {
+		return responseErr
+	}
+	runner, responseErr := cs.runnersRepository.GetRunner(ctx, runnerID)
+	if responseErr != nil {
+		return responseErr
+	}
+	if runner.ID == "" {
+		return &models.ResponseError{
+			Message: "Runner not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	return cs.coachesRepository.AssignAthlete(ctx, coach.ID, runnerID)
+}
+
+func (cs CoachesService) UnassignAthlete(ctx context.Context, username,
+	runnerID string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "CoachesService.UnassignAthlete")
+	defer span.End()
+	coach, responseErr := cs.getCoach(ctx, username)
+	if responseErr != nil {
+		return responseErr
+	}
+	responseErr = validateRunnerID(runnerID)
+	if responseErr != nil {
+		return responseErr
+	}
+	return cs.coachesRepository.UnassignAthlete(ctx, coach.ID, runnerID)
+}
+
+// GetAthletes returns the runners assigned to the coach named username.
+// Without models.PermissionCoachManage, a coach only sees their own athletes.
+func (cs CoachesService) GetAthletes(ctx context.Context, user *models.User,
+	username string) ([]*models.Runner, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "CoachesService.GetAthletes")
+	defer span.End()
+	if !user.HasPermission(models.PermissionCoachManage) &&
+		user.Username != username {
+		return nil, insufficientPermissions()
+	}
+	coach, responseErr := cs.getCoach(ctx, username)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return cs.coachesRepository.GetAthletes(ctx, coach.ID)
+}
+
+// AuthorizeRunner checks that user may act for a runner: users with
+// models.PermissionRunnerAny act for every runner, coaches for the runners
+// assigned to them.
+func (cs CoachesService) AuthorizeRunner(ctx context.Context, user *models.User,
+	runnerID string) *models.ResponseError {
+	if user.HasPermission(models.PermissionRunnerAny) {
+		return nil
+	}
+	assigned, responseErr := cs.coachesRepository.IsAssigned(ctx, user.ID,
+		runnerID)
+	if responseErr != nil {
+		return responseErr
+	}
+	if !assigned {
+		return insufficientPermissions()
+	}
+	return nil
+}
+
+func (cs CoachesService) getCoach(ctx context.Context,
+	username string) (*models.User, *models.ResponseError) {
+	if username == "" {
+		return nil, &models.ResponseError{
+			Message: "Invalid username",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	coach, responseErr := cs.usersRepository.GetUser(ctx, username)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if coach == nil {
+		return nil, &models.ResponseError{
+			Message: "User not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	if !isCoach(coach) {
+		return nil, &models.ResponseError{
+			Message: "User is not a coach",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return coach, nil
+}
+
+// isCoach reports whether user has a permission scoped to assigned athletes.
+func isCoach(user *models.User) bool {
+	return !user.HasPermission(models.PermissionRunnerAny) &&
+		(user.HasPermission(models.PermissionResultWrite) ||
+			user.HasPermission(models.PermissionResultAnnotate))
+}
+
+func insufficientPermissions() *models.ResponseError {
+	return &models.ResponseError{
+		Message: "Insufficient permissions",
+		Status:  http.StatusUnauthorized,
+	}
+}
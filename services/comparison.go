@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"github.com/fentezi/runnerBook/models"
+	"sort"
+	"strings"
+)
+
+// compareRunners compares runners a and b, with their results, race by race.
+// Results of the same race share the location, ignoring case, the year and
+// the distance; when both results carry a race date they must also share it,
+// so that two races at the same location in one year are kept apart. Races
+// are ordered by date.
+func compareRunners(a, b *models.Runner) *models.Comparison {
+	comparison := &models.Comparison{
+		RunnerA: a,
+		RunnerB: b,
+		Bests: []*models.BestComparison{
+			compareBests(models.BestPersonal, a, b, a.PersonalBest, b.PersonalBest),
+			compareBests(models.BestSeason, a, b, a.SeasonBest, b.SeasonBest),
+		},
+		Races:  []*models.HeadToHeadRace{},
+		Record: &models.HeadToHeadRecord{},
+	}
+	finishedB := map[string][]*models.Result{}
+	for _, result := range b.Results {
+		if result.Status == models.StatusFinished {
+			key := raceKey(result)
+			finishedB[key] = append(finishedB[key], result)
+		}
+	}
+	for _, resultA := range a.Results {
+		if resultA.Status != models.StatusFinished {
+			continue
+		}
+		key := raceKey(resultA)
+		resultB := pairResult(resultA, finishedB[key])
+		if resultB == nil {
+			continue
+		}
+		finishedB[key] = removeResult(finishedB[key], resultB)
+		gap := resultA.RaceResult - resultB.RaceResult
+		if gap < 0 {
+			gap = -gap
+		}
+		race := &models.HeadToHeadRace{
+			Location: resultA.Location,
+			Year:     resultA.Year,
+			Distance: resultA.Distance,
+			ResultA:  resultA,
+			ResultB:  resultB,
+			Gap:      gap,
+		}
+		switch aheadOf(resultA, resultB) {
+		case -1:
+			race.Ahead = a.ID
+			comparison.Record.WinsA++
+		case 1:
+			race.Ahead = b.ID
+			comparison.Record.WinsB++
+		default:
+			comparison.Record.Ties++
+		}
+		comparison.Races = append(comparison.Races, race)
+	}
+	sort.SliceStable(comparison.Races, func(i, j int) bool {
+		return resultDate(comparison.Races[i].ResultA).Before(
+			resultDate(comparison.Races[j].ResultA))
+	})
+	a.Results, b.Results = nil, nil
+	return comparison
+}
+
+func raceKey(result *models.Result) string {
+	return fmt.Sprintf("%s|%d|%d", strings.ToLower(strings.TrimSpace(result.Location)),
+		result.Year, result.Distance)
+}
+
+// pairResult returns the candidate from the same race as result: the one run
+// on the same date, or, when either side has no race date, the first one
+// left.
+func pairResult(result *models.Result, candidates []*models.Result) *models.Result {
+	for _, candidate := range candidates {
+		if candidate.RaceDate == result.RaceDate {
+			return candidate
+		}
+	}
+	for _, candidate := range candidates {
+		if candidate.RaceDate == "" || result.RaceDate == "" {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func removeResult(results []*models.Result, result *models.Result) []*models.Result {
+	for i, candidate := range results {
+		if candidate == result {
+			return append(results[:i:i], results[i+1:]...)
+		}
+	}
+	return results
+}
+
+// aheadOf returns -1 when a placed ahead of b, 1 when b placed ahead of a and
+// 0 for a tie.
+func aheadOf(a, b *models.Result) int {
+	if a.Position > 0 && b.Position > 0 && a.Position != b.Position {
+		if a.Position < b.Position {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case a.RaceResult == 0 || b.RaceResult == 0 || a.RaceResult == b.RaceResult:
+		return 0
+	case a.RaceResult < b.RaceResult:
+		return -1
+	}
+	return 1
+}
+
+func compareBests(kind string, a, b *models.Runner,
+	bestA, bestB models.RaceTime) *models.BestComparison {
+	comparison := &models.BestComparison{Kind: kind, A: bestA, B: bestB}
+	if bestA == 0 || bestB == 0 || bestA == bestB {
+		return comparison
+	}
+	if bestA < bestB {
+		comparison.Ahead, comparison.Gap = a.ID, bestB-bestA
+	} else {
+		comparison.Ahead, comparison.Gap = b.ID, bestA-bestB
+	}
+	return comparison
+}
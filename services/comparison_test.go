@@ -0,0 +1,103 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestCompareRunners(t *testing.T) {
+	result := func(location string, year, distance, minutes, position int,
+		status string) *models.Result {
+		return &models.Result{
+			Location:   location,
+			Year:       year,
+			Distance:   distance,
+			RaceResult: models.RaceTime(time.Duration(minutes) * time.Minute),
+			Position:   position,
+			Status:     status,
+		}
+	}
+	a := &models.Runner{
+		ID:           "a",
+		PersonalBest: models.RaceTime(150 * time.Minute),
+		SeasonBest:   models.RaceTime(155 * time.Minute),
+		Results: []*models.Result{
+			result("Berlin", 2023, 42195, 150, 10, models.StatusFinished),
+			result("London", 2022, 42195, 160, 0, models.StatusFinished),
+			result("Boston", 2023, 42195, 165, 40, models.StatusFinished),
+			result("Paris", 2023, 42195, 0, 0, models.StatusDidNotFinish),
+			result("Berlin", 2023, 10000, 34, 3, models.StatusFinished),
+			result("Tokyo", 2021, 42195, 170, 0, models.StatusFinished),
+		},
+	}
+	b := &models.Runner{
+		ID:           "b",
+		PersonalBest: models.RaceTime(148 * time.Minute),
+		Results: []*models.Result{
+			result("berlin ", 2023, 42195, 152, 12, models.StatusFinished),
+			result("London", 2022, 42195, 158, 0, models.StatusFinished),
+			// Placed ahead with a slower chip time.
+			result("Boston", 2023, 42195, 164, 45, models.StatusFinished),
+			result("Paris", 2023, 42195, 180, 0, models.StatusFinished),
+			result("Tokyo", 2021, 42195, 170, 0, models.StatusFinished),
+			result("New York", 2023, 42195, 160, 0, models.StatusFinished),
+		},
+	}
+	comparison := compareRunners(a, b)
+
+	assert.Equal(t, comparison.Bests, []*models.BestComparison{
+		{Kind: models.BestPersonal, A: a.PersonalBest, B: b.PersonalBest,
+			Ahead: "b", Gap: models.RaceTime(2 * time.Minute)},
+		{Kind: models.BestSeason, A: a.SeasonBest},
+	})
+	type race struct {
+		location string
+		year     int
+		ahead    string
+		gap      time.Duration
+	}
+	races := []race{}
+	for _, headToHead := range comparison.Races {
+		races = append(races, race{headToHead.Location, headToHead.Year,
+			headToHead.Ahead, headToHead.Gap.Duration()})
+	}
+	assert.Equal(t, races, []race{
+		{"Tokyo", 2021, "", 0},
+		{"London", 2022, "b", 2 * time.Minute},
+		{"Berlin", 2023, "a", 2 * time.Minute},
+		{"Boston", 2023, "a", time.Minute},
+	})
+	assert.Equal(t, *comparison.Record, models.HeadToHeadRecord{WinsA: 2, WinsB: 1, Ties: 1})
+	assert.Equal(t, comparison.RunnerA.Results == nil, true)
+}
+
+func TestCompareRunnersSameLocationAndYear(t *testing.T) {
+	result := func(raceDate string, minutes int) *models.Result {
+		return &models.Result{
+			Location:   "Berlin",
+			Year:       2023,
+			Distance:   10000,
+			RaceDate:   raceDate,
+			RaceResult: models.RaceTime(time.Duration(minutes) * time.Minute),
+			Status:     models.StatusFinished,
+		}
+	}
+	a := &models.Runner{ID: "a", Results: []*models.Result{
+		result("2023-09-24", 34), result("2023-04-02", 36), result("2023-12-31", 35)}}
+	b := &models.Runner{ID: "b", Results: []*models.Result{
+		result("2023-04-02", 35), result("2023-09-24", 35), result("2023-06-18", 33)}}
+	comparison := compareRunners(a, b)
+
+	type race struct {
+		date  string
+		ahead string
+	}
+	races := []race{}
+	for _, headToHead := range comparison.Races {
+		races = append(races, race{headToHead.ResultA.RaceDate, headToHead.Ahead})
+	}
+	assert.Equal(t, races, []race{{"2023-04-02", "b"}, {"2023-09-24", "a"}})
+	assert.Equal(t, *comparison.Record, models.HeadToHeadRecord{WinsA: 1, WinsB: 1})
+}
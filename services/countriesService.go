@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+)
+
+type CountriesService struct {
+	countriesRepository *repositories.CountriesRepository
+}
+
+func NewCountriesService(
+	countriesRepository *repositories.CountriesRepository) *CountriesService {
+	return &CountriesService{
+		countriesRepository: countriesRepository,
+	}
+}
+
+func (cs CountriesService) GetCountries(ctx context.Context) ([]*models.Country, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "CountriesService.GetCountries")
+	defer span.End()
+	return cs.countriesRepository.GetCountries(ctx)
+}
+
+// NormalizeCountry returns the alpha-3 code of country, given as a code, a
+// name or an alias. An empty country stays empty.
+func (cs CountriesService) NormalizeCountry(ctx context.Context,
+	country string) (string, *models.ResponseError) {
+	if country == "" {
+		return "", nil
+	}
+	alpha3, responseErr := cs.countriesRepository.FindCountry(ctx, country)
+	if responseErr != nil {
+		return "", responseErr
+	}
+	if alpha3 == "" {
+		return "", &models.ResponseError{
+			Message: "Invalid country",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return alpha3, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/fentezi/runnerBook/dbscripts"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+	"time"
+)
+
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type MigrationsService struct {
+	schemaRepository *repositories.SchemaRepository
+}
+
+func NewMigrationsService(schemaRepository *repositories.SchemaRepository) *MigrationsService {
+	return &MigrationsService{schemaRepository: schemaRepository}
+}
+
+// Status lists every bundled migration and when it was applied; pending
+// migrations have no AppliedAt.
+func (ms MigrationsService) Status(ctx context.Context) ([]*MigrationStatus, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "MigrationsService.Status")
+	defer span.End()
+	migrations, applied, responseErr := ms.load(ctx)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	statuses := make([]*MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := &MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied.
+func (ms MigrationsService) Up(ctx context.Context) ([]*dbscripts.Migration, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "MigrationsService.Up")
+	defer span.End()
+	migrations, applied, responseErr := ms.load(ctx)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	done := make([]*dbscripts.Migration, 0)
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		responseErr = ms.schemaRepository.ApplyMigration(ctx,
+			migration.Version, migration.Up, true)
+		if responseErr != nil {
+			responseErr.Message = fmt.Sprintf("Migration %d_%s failed: %s",
+				migration.Version, migration.Name, responseErr.Message)
+			return done, responseErr
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverts the newest steps applied migrations and returns the ones it
+// reverted.
+func (ms MigrationsService) Down(ctx context.Context, steps int) ([]*dbscripts.Migration, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "MigrationsService.Down")
+	defer span.End()
+	if steps <= 0 {
+		return nil, &models.ResponseError{
+			Message: "Invalid number of steps",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	migrations, applied, responseErr := ms.load(ctx)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	done := make([]*dbscripts.Migration, 0)
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, &models.ResponseError{
+				Message: fmt.Sprintf("Migration %d_%s cannot be reverted",
+					migration.Version, migration.Name),
+				Status: http.StatusBadRequest,
+			}
+		}
+		responseErr = ms.schemaRepository.ApplyMigration(ctx,
+			migration.Version, migration.Down, false)
+		if responseErr != nil {
+			responseErr.Message = fmt.Sprintf("Reverting migration %d_%s failed: %s",
+				migration.Version, migration.Name, responseErr.Message)
+			return done, responseErr
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+func (ms MigrationsService) load(ctx context.Context) ([]*dbscripts.Migration, map[int]time.Time, *models.ResponseError) {
+	migrations, err := dbscripts.Migrations()
+	if err != nil {
+		return nil, nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	created, responseErr := ms.schemaRepository.CreateMigrationsTable(ctx)
+	if responseErr != nil {
+		return nil, nil, responseErr
+	}
+	if created {
+		responseErr = ms.adoptSchema(ctx)
+		if responseErr != nil {
+			return nil, nil, responseErr
+		}
+	}
+	applied, responseErr := ms.schemaRepository.GetAppliedMigrations(ctx)
+	if responseErr != nil {
+		return nil, nil, responseErr
+	}
+	return migrations, applied, nil
+}
+
+// bootstrapTables are the tables created by the migrations databases were
+// set up with by hand, from public_schema.sql and update_schema.sql, before
+// schema_migrations existed.
+var bootstrapTables = []struct {
+	version int
+	table   string
+}{
+	{1, "runners"},
+	{2, "users"},
+}
+
+// adoptSchema records the bootstrap migrations of a database set up by hand
+// as applied, so that migrating it does not create its tables again.
+func (ms MigrationsService) adoptSchema(ctx context.Context) *models.ResponseError {
+	for _, bootstrap := range bootstrapTables {
+		exists, responseErr := ms.schemaRepository.TableExists(ctx,
+			bootstrap.table)
+		if responseErr != nil {
+			return responseErr
+		}
+		if !exists {
+			return nil
+		}
+		responseErr = ms.schemaRepository.ApplyMigration(ctx,
+			bootstrap.version, "", true)
+		if responseErr != nil {
+			return responseErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestStatusAdoptsHandBuiltSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		want     []int
+	}{
+		{"empty database", nil, []int{}},
+		{"runners only", []string{"runners"}, []int{1}},
+		{"runners and users", []string{"runners", "users"}, []int{1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dbHandler, mock, _ := sqlmock.New()
+			defer dbHandler.Close()
+			mock.ExpectQuery("SELECT to_regclass").WithArgs("schema_migrations").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			for i, table := range []string{"runners", "users"} {
+				exists := i < len(test.existing)
+				mock.ExpectQuery("SELECT to_regclass").WithArgs(table).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+				if !exists {
+					break
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(i + 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+			rows := sqlmock.NewRows([]string{"version", "applied_at"})
+			for _, version := range test.want {
+				rows.AddRow(version, time.Now())
+			}
+			mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+				WillReturnRows(rows)
+			migrationsService := NewMigrationsService(
+				repositories.NewSchemaRepository(dbHandler))
+			statuses, responseErr := migrationsService.Status(context.Background())
+			assert.Equal(t, responseErr == nil, true)
+			applied := make([]int, 0)
+			for _, status := range statuses {
+				if status.AppliedAt != nil {
+					applied = append(applied, status.Version)
+				}
+			}
+			assert.Equal(t, applied, test.want)
+			assert.Equal(t, mock.ExpectationsWereMet(), nil)
+		})
+	}
+}
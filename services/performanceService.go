@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/fentezi/runnerBook/tracing"
+	"math"
+	"net/http"
+	"time"
+)
+
+// minSourceDistance is the shortest result, in metres, performances are
+// derived from. The formulas do not hold for sprints and middle distances.
+const minSourceDistance = 3000
+
+type PerformanceService struct {
+	runnersRepository *repositories.RunnersRepository
+	resultsRepository *repositories.ResultsRepository
+	windowDays        int
+}
+
+// NewPerformanceService creates a service that derives performances from the
+// results of the last windowDays days.
+func NewPerformanceService(runnersRepository *repositories.RunnersRepository,
+	resultsRepository *repositories.ResultsRepository,
+	windowDays int) *PerformanceService {
+	return &PerformanceService{
+		runnersRepository: runnersRepository,
+		resultsRepository: resultsRepository,
+		windowDays:        windowDays,
+	}
+}
+
+// GetPredictions predicts the runner's times over the standard distances
+// with model, riegel by default, from their recent results.
+func (ps PerformanceService) GetPredictions(ctx context.Context, runnerID,
+	model string) (*models.Predictions, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "PerformanceService.GetPredictions")
+	defer span.End()
+	if model == "" {
+		model = racecalc.ModelRiegel
+	}
+	if model != racecalc.ModelRiegel && model != racecalc.ModelVDOT {
+		return nil, &models.ResponseError{
+			Message: "Invalid model",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	since, sources, responseErr := ps.recentResults(ctx, runnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	predictions := predict(model, sources, racecalc.StandardDistances)
+	predictions.RunnerID = runnerID
+	predictions.Since = since.Format(dateLayout)
+	return predictions, nil
+}
+
+// GetPaces returns the runner's training paces per unit, km by default or
+// mile, derived from the VDOT of their best recent result.
+func (ps PerformanceService) GetPaces(ctx context.Context, runnerID,
+	unit string) (*models.TrainingPaces, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "PerformanceService.GetPaces")
+	defer span.End()
+	if unit == "" {
+		unit = racecalc.UnitKilometre
+	}
+	if unit != racecalc.UnitKilometre && unit != racecalc.UnitMile {
+		return nil, &models.ResponseError{
+			Message: "Invalid unit",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	since, sources, responseErr := ps.recentResults(ctx, runnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	source, vdot := bestPerformance(sources)
+	paces, err := racecalc.TrainingPaces(vdot, unit)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: err.Error(),
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	trainingPaces := &models.TrainingPaces{
+		RunnerID: runnerID,
+		VDOT:     math.Round(vdot*10) / 10,
+		Unit:     unit,
+		Since:    since.Format(dateLayout),
+		Source:   source,
+		Paces:    make([]*models.TrainingPace, 0, len(paces)),
+	}
+	for _, pace := range paces {
+		trainingPaces.Paces = append(trainingPaces.Paces, &models.TrainingPace{
+			Zone: pace.Zone,
+			Slow: models.RaceTime(pace.Slow),
+			Fast: models.RaceTime(pace.Fast),
+		})
+	}
+	return trainingPaces, nil
+}
+
+// GetStats returns the race counts, yearly bests and progression of the
+// runner.
+func (ps PerformanceService) GetStats(ctx context.Context,
+	runnerID string) (*models.RunnerStats, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "PerformanceService.GetStats")
+	defer span.End()
+	results, responseErr := ps.runnerResults(ctx, runnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return runnerStats(runnerID, results), nil
+}
+
+// recentResults returns the start of the window and the results the runner
+// finished in it over at least minSourceDistance.
+func (ps PerformanceService) recentResults(ctx context.Context,
+	runnerID string) (time.Time, []*models.Result, *models.ResponseError) {
+	results, responseErr := ps.runnerResults(ctx, runnerID)
+	if responseErr != nil {
+		return time.Time{}, nil, responseErr
+	}
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day()-ps.windowDays,
+		0, 0, 0, 0, time.UTC)
+	recent := make([]*models.Result, 0, len(results))
+	for _, result := range results {
+		if result.Status == models.StatusFinished && result.RaceResult > 0 &&
+			result.Distance >= minSourceDistance &&
+			!resultDate(result).Before(since) {
+			recent = append(recent, result)
+		}
+	}
+	if len(recent) == 0 {
+		return time.Time{}, nil, &models.ResponseError{
+			Message: "No recent results",
+			Status:  http.StatusNotFound,
+		}
+	}
+	return since, recent, nil
+}
+
+// runnerResults returns the results of the runner, failing when there is no
+// such runner.
+func (ps PerformanceService) runnerResults(ctx context.Context,
+	runnerID string) ([]*models.Result, *models.ResponseError) {
+	responseErr := validateRunnerID(runnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	runner, responseErr := ps.runnersRepository.GetRunner(ctx, runnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if runner.ID == "" {
+		return nil, &models.ResponseError{
+			Message: "Runner not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	return ps.resultsRepository.GetAllRunnersResults(ctx, runnerID)
+}
+
+// predict predicts the times over distances from sources. The riegel model
+// predicts every distance from the source closest to it, the faster
+// prediction breaking ties. The vdot model predicts every distance from the
+// source with the highest VDOT. Times are rounded to the second.
+func predict(model string, sources []*models.Result,
+	distances []int) *models.Predictions {
+	predictions := &models.Predictions{
+		Model:       model,
+		Sources:     []*models.Result{},
+		Predictions: make([]*models.Prediction, 0, len(distances)),
+	}
+	used := map[string]bool{}
+	use := func(source *models.Result, distance int, raceTime time.Duration) {
+		predictions.Predictions = append(predictions.Predictions,
+			&models.Prediction{
+				Distance:       distance,
+				Time:           models.RaceTime(raceTime.Round(time.Second)),
+				SourceResultID: source.ID,
+			})
+		if !used[source.ID] {
+			used[source.ID] = true
+			predictions.Sources = append(predictions.Sources, source)
+		}
+	}
+	if model == racecalc.ModelVDOT {
+		best, bestVDOT := bestPerformance(sources)
+		predictions.VDOT = math.Round(bestVDOT*10) / 10
+		for _, distance := range distances {
+			use(best, distance, racecalc.VDOTTime(bestVDOT, distance))
+		}
+		return predictions
+	}
+	for _, distance := range distances {
+		var best *models.Result
+		var bestTime time.Duration
+		bestGap := 0.0
+		for _, source := range sources {
+			gap := math.Abs(math.Log(float64(distance) / float64(source.Distance)))
+			raceTime := racecalc.Riegel(source.Distance,
+				source.RaceResult.Duration(), distance)
+			if best == nil || gap < bestGap ||
+				gap == bestGap && raceTime < bestTime {
+				best, bestTime, bestGap = source, raceTime, gap
+			}
+		}
+		use(best, distance, bestTime)
+	}
+	return predictions
+}
+
+// bestPerformance returns the source with the highest VDOT and its VDOT.
+func bestPerformance(sources []*models.Result) (*models.Result, float64) {
+	var best *models.Result
+	bestVDOT := 0.0
+	for _, source := range sources {
+		vdot := racecalc.VDOT(source.Distance, source.RaceResult.Duration())
+		if best == nil || vdot > bestVDOT {
+			best, bestVDOT = source, vdot
+		}
+	}
+	return best, bestVDOT
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestPredict(t *testing.T) {
+	tenK := &models.Result{ID: "10k", Distance: 10000,
+		RaceResult: models.RaceTime(40 * time.Minute)}
+	slowTenK := &models.Result{ID: "slow-10k", Distance: 10000,
+		RaceResult: models.RaceTime(45 * time.Minute)}
+	half := &models.Result{ID: "half", Distance: 21098,
+		RaceResult: models.RaceTime(90 * time.Minute)}
+	tests := []struct {
+		name        string
+		model       string
+		sources     []*models.Result
+		wantTimes   []time.Duration
+		wantSources []string
+		wantVDOT    float64
+	}{
+		{
+			name:        "Riegel_Closest_Source",
+			model:       racecalc.ModelRiegel,
+			sources:     []*models.Result{tenK, half},
+			wantTimes:   []time.Duration{1151 * time.Second, 40 * time.Minute, 90 * time.Minute, 11258 * time.Second},
+			wantSources: []string{"10k", "10k", "half", "half"},
+		},
+		{
+			name:        "Riegel_Faster_Source_Breaks_Tie",
+			model:       racecalc.ModelRiegel,
+			sources:     []*models.Result{slowTenK, tenK},
+			wantTimes:   []time.Duration{1151 * time.Second, 40 * time.Minute, 5296 * time.Second, 11040 * time.Second},
+			wantSources: []string{"10k", "10k", "10k", "10k"},
+		},
+		{
+			name:        "VDOT_Best_Source",
+			model:       racecalc.ModelVDOT,
+			sources:     []*models.Result{slowTenK, tenK},
+			wantTimes:   []time.Duration{1158 * time.Second, 40 * time.Minute, 5313 * time.Second, 11078 * time.Second},
+			wantSources: []string{"10k", "10k", "10k", "10k"},
+			wantVDOT:    51.9,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := predict(test.model, test.sources, racecalc.StandardDistances)
+			assert.Equal(t, got.Model, test.model)
+			assert.Equal(t, got.VDOT, test.wantVDOT)
+			for i, prediction := range got.Predictions {
+				assert.Equal(t, prediction.Distance, racecalc.StandardDistances[i])
+				assert.Equal(t, prediction.Time.Duration(), test.wantTimes[i])
+				assert.Equal(t, prediction.SourceResultID, test.wantSources[i])
+			}
+			sources := map[string]bool{}
+			for _, source := range test.wantSources {
+				sources[source] = true
+			}
+			assert.Equal(t, len(got.Sources), len(sources))
+		})
+	}
+}
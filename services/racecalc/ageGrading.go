@@ -0,0 +1,148 @@
+// Package racecalc holds the running calculations the services build on:
+// age grading, race time prediction and training paces.
+package racecalc
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed data/age_grading.csv
+var defaultAgeGrading string
+
+// AgeGradingTable holds open-class standards and age factors per category
+// and distance.
+type AgeGradingTable struct {
+	ages []int
+	rows map[string][]ageGradingRow
+}
+
+type ageGradingRow struct {
+	distance int
+	standard float64
+	factors  []float64
+}
+
+// DefaultAgeGradingTable returns the table bundled with the application. Its
+// factors approximate the WMA road tables from age 30 on and cover categories
+// M and W only; see data/age_grading.csv.
+func DefaultAgeGradingTable() *AgeGradingTable {
+	table, err := LoadAgeGradingTable(strings.NewReader(defaultAgeGrading))
+	if err != nil {
+		panic("racecalc: bundled age grading table: " + err.Error())
+	}
+	return table
+}
+
+// LoadAgeGradingTable reads a table in the format of data/age_grading.csv:
+// category, distance in metres and open-class standard in seconds, followed
+// by a factor for every age in the header. Lines starting with # are
+// comments.
+func LoadAgeGradingTable(reader io.Reader) (*AgeGradingTable, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comment = '#'
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) < 4 || header[0] != "category" ||
+		header[1] != "distance" || header[2] != "standard" {
+		return nil, errors.New("header must start with category,distance,standard and list ages")
+	}
+	table := &AgeGradingTable{rows: map[string][]ageGradingRow{}}
+	for _, column := range header[3:] {
+		age, err := strconv.Atoi(strings.TrimSpace(column))
+		if err != nil || len(table.ages) > 0 && age <= table.ages[len(table.ages)-1] {
+			return nil, fmt.Errorf("invalid age column %q, ages must increase", column)
+		}
+		table.ages = append(table.ages, age)
+	}
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+		row := ageGradingRow{}
+		row.distance, err = strconv.Atoi(record[1])
+		if err != nil || row.distance <= 0 {
+			return nil, fmt.Errorf("line %d: invalid distance %q", line, record[1])
+		}
+		row.standard, err = strconv.ParseFloat(record[2], 64)
+		if err != nil || row.standard <= 0 {
+			return nil, fmt.Errorf("line %d: invalid standard %q", line, record[2])
+		}
+		for _, field := range record[3:] {
+			factor, err := strconv.ParseFloat(field, 64)
+			if err != nil || factor <= 0 || factor > 1 {
+				return nil, fmt.Errorf("line %d: invalid factor %q", line, field)
+			}
+			row.factors = append(row.factors, factor)
+		}
+		table.rows[record[0]] = append(table.rows[record[0]], row)
+	}
+	for _, rows := range table.rows {
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i].distance < rows[j].distance
+		})
+	}
+	return table, nil
+}
+
+// AgeGrade returns the age grade, as a percentage, of a race run at age in
+// category over distance metres. It reports false when the table has no
+// standard for the category or the distance is outside the distances the
+// table covers. Between two distances of the table the standard is
+// interpolated on a log-log scale and the factor linearly.
+func (t *AgeGradingTable) AgeGrade(category string, age, distance int,
+	raceTime time.Duration) (float64, bool) {
+	rows := t.rows[category]
+	if len(rows) == 0 || raceTime <= 0 ||
+		distance < rows[0].distance || distance > rows[len(rows)-1].distance {
+		return 0, false
+	}
+	i := sort.Search(len(rows), func(i int) bool {
+		return rows[i].distance >= distance
+	})
+	standard, factor := rows[i].standard, t.factor(rows[i], age)
+	if rows[i].distance != distance {
+		lower, upper := rows[i-1], rows[i]
+		exponent := math.Log(upper.standard/lower.standard) /
+			math.Log(float64(upper.distance)/float64(lower.distance))
+		standard = lower.standard * math.Pow(float64(distance)/float64(lower.distance), exponent)
+		fraction := float64(distance-lower.distance) / float64(upper.distance-lower.distance)
+		lowerFactor := t.factor(lower, age)
+		factor = lowerFactor + fraction*(t.factor(upper, age)-lowerFactor)
+	}
+	ageStandard := standard / factor
+	return math.Round(ageStandard/raceTime.Seconds()*10000) / 100, true
+}
+
+// factor interpolates the factor of row at age, using the first and last
+// factors for ages outside the table.
+func (t *AgeGradingTable) factor(row ageGradingRow, age int) float64 {
+	if age <= t.ages[0] {
+		return row.factors[0]
+	}
+	last := len(t.ages) - 1
+	if age >= t.ages[last] {
+		return row.factors[last]
+	}
+	i := sort.SearchInts(t.ages, age)
+	if t.ages[i] == age {
+		return row.factors[i]
+	}
+	fraction := float64(age-t.ages[i-1]) / float64(t.ages[i]-t.ages[i-1])
+	return row.factors[i-1] + fraction*(row.factors[i]-row.factors[i-1])
+}
@@ -0,0 +1,78 @@
+package racecalc
+
+import (
+	"github.com/magiconair/properties/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTable = `category,distance,standard,30,40,50
+M,10000,1600,1.0,0.95,0.9
+M,40000,7200,1.0,0.9,0.8
+`
+
+func TestAgeGrade(t *testing.T) {
+	table, err := LoadAgeGradingTable(strings.NewReader(testTable))
+	assert.Equal(t, nil, err)
+	tests := []struct {
+		name      string
+		category  string
+		age       int
+		distance  int
+		raceTime  time.Duration
+		want      float64
+		wantFound bool
+	}{
+		{"Open_Standard", "M", 30, 10000, 1600 * time.Second, 100, true},
+		{"Younger_Than_Table", "M", 20, 10000, 3200 * time.Second, 50, true},
+		{"Table_Age", "M", 50, 40000, 9000 * time.Second, 100, true},
+		// factor 0.925 at 45: 1600 / 0.925 / 2000
+		{"Interpolated_Age", "M", 45, 10000, 2000 * time.Second, 86.49, true},
+		// standard 1600 * 2^(log(4.5)/log(4)) = 3394.1, factor 1.0
+		{"Interpolated_Distance", "M", 30, 20000, 3394 * time.Second, 100, true},
+		{"Older_Than_Table", "M", 90, 40000, 9000 * time.Second, 100, true},
+		{"Unknown_Category", "X", 30, 10000, 1600 * time.Second, 0, false},
+		{"Distance_Too_Short", "M", 30, 5000, 800 * time.Second, 0, false},
+		{"Distance_Too_Long", "M", 30, 50000, 9000 * time.Second, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grade, found := table.AgeGrade(test.category, test.age,
+				test.distance, test.raceTime)
+			assert.Equal(t, test.wantFound, found)
+			assert.Equal(t, test.want, grade)
+		})
+	}
+}
+
+func TestLoadAgeGradingTableErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"Empty":             "",
+		"Bad_Header":        "category,distance\n",
+		"Decreasing_Ages":   "category,distance,standard,40,30\n",
+		"Invalid_Distance":  "category,distance,standard,30\nM,ten,1600,1.0\n",
+		"Invalid_Factor":    "category,distance,standard,30\nM,10000,1600,1.5\n",
+		"Missing_Factor":    "category,distance,standard,30,40\nM,10000,1600,1.0\n",
+		"Negative_Standard": "category,distance,standard,30\nM,10000,-1,1.0\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadAgeGradingTable(strings.NewReader(data))
+			assert.Equal(t, true, err != nil)
+		})
+	}
+}
+
+func TestDefaultAgeGradingTable(t *testing.T) {
+	table := DefaultAgeGradingTable()
+	grade, found := table.AgeGrade("W", 30, 42195, 8125*time.Second)
+	assert.Equal(t, true, found)
+	assert.Equal(t, 100.0, grade)
+	// no junior adjustment below the first age of the table
+	grade, found = table.AgeGrade("M", 20, 10000, 1603*time.Second)
+	assert.Equal(t, true, found)
+	assert.Equal(t, 100.0, grade)
+	// no standards for category X
+	_, found = table.AgeGrade("X", 30, 10000, 1603*time.Second)
+	assert.Equal(t, false, found)
+}
@@ -0,0 +1,87 @@
+package racecalc
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Training zones, from the slowest to the fastest.
+const (
+	ZoneEasy       = "easy"
+	ZoneMarathon   = "marathon"
+	ZoneThreshold  = "threshold"
+	ZoneInterval   = "interval"
+	ZoneRepetition = "repetition"
+)
+
+// Pace units.
+const (
+	UnitKilometre = "km"
+	UnitMile      = "mile"
+)
+
+// ErrUnknownUnit is returned for a pace unit other than km or mile.
+var ErrUnknownUnit = errors.New("unknown pace unit")
+
+var unitLengths = map[string]float64{
+	UnitKilometre: 1000,
+	UnitMile:      1609.344,
+}
+
+// zoneIntensities are the fractions of VDOT the zones are run at, as the
+// slowest and fastest end of the zone. The marathon zone is race pace
+// instead.
+var zoneIntensities = []struct {
+	zone       string
+	slow, fast float64
+}{
+	{ZoneEasy, 0.62, 0.70},
+	{ZoneMarathon, 0, 0},
+	{ZoneThreshold, 0.88, 0.88},
+	{ZoneInterval, 0.975, 0.975},
+	{ZoneRepetition, 1.05, 1.05},
+}
+
+// Pace is the time per unit of a training zone. Zones run at a single pace
+// have the same Slow and Fast pace.
+type Pace struct {
+	Zone string
+	Slow time.Duration
+	Fast time.Duration
+}
+
+// TrainingPaces returns the paces per unit, km or mile, of the training zones
+// of a runner with vdot, rounded to the second.
+func TrainingPaces(vdot float64, unit string) ([]Pace, error) {
+	length, ok := unitLengths[unit]
+	if !ok {
+		return nil, ErrUnknownUnit
+	}
+	paces := make([]Pace, 0, len(zoneIntensities))
+	for _, intensity := range zoneIntensities {
+		pace := Pace{Zone: intensity.zone}
+		if intensity.zone == ZoneMarathon {
+			marathon := VDOTTime(vdot, 42195)
+			pace.Slow = perUnit(42195/marathon.Minutes(), length)
+			pace.Fast = pace.Slow
+		} else {
+			pace.Slow = perUnit(velocity(intensity.slow*vdot), length)
+			pace.Fast = perUnit(velocity(intensity.fast*vdot), length)
+		}
+		paces = append(paces, pace)
+	}
+	return paces, nil
+}
+
+// velocity returns the speed, in metres per minute, whose oxygen cost is
+// oxygenCost, solving Daniels and Gilbert's oxygen cost formula.
+func velocity(oxygenCost float64) float64 {
+	a, b, c := 0.000104, 0.182258, -4.60-oxygenCost
+	return (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+}
+
+func perUnit(velocity, length float64) time.Duration {
+	minutes := length / velocity
+	return time.Duration(minutes * float64(time.Minute)).Round(time.Second)
+}
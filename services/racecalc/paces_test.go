@@ -0,0 +1,139 @@
+package racecalc
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func pace(minutes, seconds int) time.Duration {
+	return time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+}
+
+func TestTrainingPaces(t *testing.T) {
+	// Within a second or two of the tables in Daniels' Running Formula.
+	tests := []struct {
+		name string
+		vdot float64
+		unit string
+		want []Pace
+	}{
+		{"VDOT_30_Km", 30, UnitKilometre, []Pace{
+			{ZoneEasy, pace(8, 23), pace(7, 39)},
+			{ZoneMarathon, pace(6, 52), pace(6, 52)},
+			{ZoneThreshold, pace(6, 24), pace(6, 24)},
+			{ZoneInterval, pace(5, 54), pace(5, 54)},
+			{ZoneRepetition, pace(5, 34), pace(5, 34)},
+		}},
+		{"VDOT_40_Km", 40, UnitKilometre, []Pace{
+			{ZoneEasy, pace(6, 44), pace(6, 7)},
+			{ZoneMarathon, pace(5, 27), pace(5, 27)},
+			{ZoneThreshold, pace(5, 6), pace(5, 6)},
+			{ZoneInterval, pace(4, 41), pace(4, 41)},
+			{ZoneRepetition, pace(4, 25), pace(4, 25)},
+		}},
+		{"VDOT_50_Km", 50, UnitKilometre, []Pace{
+			{ZoneEasy, pace(5, 38), pace(5, 7)},
+			{ZoneMarathon, pace(4, 31), pace(4, 31)},
+			{ZoneThreshold, pace(4, 15), pace(4, 15)},
+			{ZoneInterval, pace(3, 55), pace(3, 55)},
+			{ZoneRepetition, pace(3, 41), pace(3, 41)},
+		}},
+		{"VDOT_50_Mile", 50, UnitMile, []Pace{
+			{ZoneEasy, pace(9, 4), pace(8, 14)},
+			{ZoneMarathon, pace(7, 16), pace(7, 16)},
+			{ZoneThreshold, pace(6, 51), pace(6, 51)},
+			{ZoneInterval, pace(6, 18), pace(6, 18)},
+			{ZoneRepetition, pace(5, 56), pace(5, 56)},
+		}},
+		{"VDOT_60_Mile", 60, UnitMile, []Pace{
+			{ZoneEasy, pace(7, 50), pace(7, 6)},
+			{ZoneMarathon, pace(6, 14), pace(6, 14)},
+			{ZoneThreshold, pace(5, 54), pace(5, 54)},
+			{ZoneInterval, pace(5, 26), pace(5, 26)},
+			{ZoneRepetition, pace(5, 7), pace(5, 7)},
+		}},
+		{"VDOT_70_Km", 70, UnitKilometre, []Pace{
+			{ZoneEasy, pace(4, 18), pace(3, 54)},
+			{ZoneMarathon, pace(3, 24), pace(3, 24)},
+			{ZoneThreshold, pace(3, 14), pace(3, 14)},
+			{ZoneInterval, pace(2, 59), pace(2, 59)},
+			{ZoneRepetition, pace(2, 48), pace(2, 48)},
+		}},
+		{"VDOT_85_Mile", 85, UnitMile, []Pace{
+			{ZoneEasy, pace(5, 55), pace(5, 22)},
+			{ZoneMarathon, pace(4, 37), pace(4, 37)},
+			{ZoneThreshold, pace(4, 27), pace(4, 27)},
+			{ZoneInterval, pace(4, 6), pace(4, 6)},
+			{ZoneRepetition, pace(3, 52), pace(3, 52)},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := TrainingPaces(test.vdot, test.unit)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, got, test.want)
+		})
+	}
+}
+
+func TestTrainingPacesUnknownUnit(t *testing.T) {
+	tests := []string{"", "KM", "miles", "m"}
+	for _, unit := range tests {
+		t.Run(unit, func(t *testing.T) {
+			_, err := TrainingPaces(50, unit)
+			assert.Equal(t, err, ErrUnknownUnit)
+		})
+	}
+}
+
+func TestTrainingPacesOrder(t *testing.T) {
+	for vdot := 30.0; vdot <= 85; vdot += 0.5 {
+		for _, unit := range []string{UnitKilometre, UnitMile} {
+			paces, _ := TrainingPaces(vdot, unit)
+			assert.Equal(t, len(paces), 5)
+			assert.Equal(t, paces[0].Slow > paces[0].Fast, true)
+			for i := 1; i < len(paces); i++ {
+				if paces[i].Slow >= paces[i-1].Fast {
+					t.Errorf("VDOT %.1f %s: %s pace %s not faster than %s pace %s",
+						vdot, unit, paces[i].Zone, paces[i].Slow,
+						paces[i-1].Zone, paces[i-1].Fast)
+				}
+			}
+		}
+	}
+}
+
+func TestTrainingPacesUnits(t *testing.T) {
+	// A mile is 1.609344 km, so mile paces are the km paces scaled by it,
+	// give or take the rounding of both to the second.
+	for _, vdot := range []float64{32, 47.5, 63} {
+		km, _ := TrainingPaces(vdot, UnitKilometre)
+		miles, _ := TrainingPaces(vdot, UnitMile)
+		for i := range km {
+			scaled := time.Duration(float64(km[i].Fast) * 1.609344)
+			difference := (scaled - miles[i].Fast).Abs()
+			assert.Equal(t, difference <= time.Second, true)
+		}
+	}
+}
+
+func TestTrainingPacesMarathonPace(t *testing.T) {
+	// Marathon pace is the pace of the predicted marathon time.
+	tests := []struct {
+		vdot float64
+		want time.Duration
+	}{
+		{40, VDOTTime(40, 42195)},
+		{50, VDOTTime(50, 42195)},
+		{70, VDOTTime(70, 42195)},
+	}
+	for _, test := range tests {
+		paces, _ := TrainingPaces(test.vdot, UnitKilometre)
+		marathon := time.Duration(float64(paces[1].Fast) * 42.195)
+		difference := (marathon - test.want).Abs()
+		// Rounding to the second moves the pace by up to half a second per
+		// km, 21 seconds over the distance.
+		assert.Equal(t, difference <= 22*time.Second, true)
+	}
+}
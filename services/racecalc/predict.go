@@ -0,0 +1,54 @@
+package racecalc
+
+import (
+	"math"
+	"time"
+)
+
+// Prediction models.
+const (
+	ModelRiegel = "riegel"
+	ModelVDOT   = "vdot"
+)
+
+// StandardDistances are the distances, in metres, race times are predicted
+// for: 5K, 10K, half marathon and marathon.
+var StandardDistances = []int{5000, 10000, 21098, 42195}
+
+// riegelExponent is the fatigue factor of Riegel's formula.
+const riegelExponent = 1.06
+
+// Riegel predicts the time over distance from a race over sourceDistance run
+// in sourceTime, with Riegel's formula t2 = t1 * (d2 / d1)^1.06.
+func Riegel(sourceDistance int, sourceTime time.Duration,
+	distance int) time.Duration {
+	ratio := float64(distance) / float64(sourceDistance)
+	return time.Duration(float64(sourceTime) * math.Pow(ratio, riegelExponent))
+}
+
+// VDOT returns the VDOT of a race over distance metres run in raceTime, from
+// Daniels and Gilbert's oxygen cost and time-to-exhaustion formulas.
+func VDOT(distance int, raceTime time.Duration) float64 {
+	minutes := raceTime.Minutes()
+	velocity := float64(distance) / minutes
+	oxygenCost := -4.60 + 0.182258*velocity + 0.000104*velocity*velocity
+	fraction := 0.8 + 0.1894393*math.Exp(-0.012778*minutes) +
+		0.2989558*math.Exp(-0.1932605*minutes)
+	return oxygenCost / fraction
+}
+
+// VDOTTime returns the time over distance of a runner with vdot, the
+// inverse of VDOT. VDOT falls as the time grows, so the time is found by
+// bisection.
+func VDOTTime(vdot float64, distance int) time.Duration {
+	low, high := time.Second, 48*time.Hour
+	for high-low > time.Millisecond {
+		middle := low + (high-low)/2
+		if VDOT(distance, middle) > vdot {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return high
+}
@@ -0,0 +1,82 @@
+package racecalc
+
+import (
+	"github.com/magiconair/properties/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRiegel(t *testing.T) {
+	tests := []struct {
+		name           string
+		sourceDistance int
+		sourceTime     time.Duration
+		distance       int
+		want           time.Duration
+	}{
+		{"Same_Distance", 10000, 40 * time.Minute, 10000, 40 * time.Minute},
+		// 40:00 * 2^1.06 = 83:24.9
+		{"Double_Distance", 10000, 40 * time.Minute, 20000, 5004 * time.Second},
+		// 1:30:00 * 2.0001^1.06 = 3:07:38
+		{"Half_To_Marathon", 21098, 90 * time.Minute, 42195, 11258 * time.Second},
+		{"Shorter_Distance", 10000, 40 * time.Minute, 5000, 1151 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Riegel(test.sourceDistance, test.sourceTime, test.distance)
+			assert.Equal(t, got.Round(time.Second), test.want)
+		})
+	}
+}
+
+func TestVDOT(t *testing.T) {
+	// Race times from Daniels' Running Formula tables.
+	tests := []struct {
+		name     string
+		distance int
+		raceTime time.Duration
+		want     float64
+	}{
+		{"5K_VDOT_30", 5000, 30*time.Minute + 40*time.Second, 30},
+		{"5K_VDOT_50", 5000, 19*time.Minute + 57*time.Second, 50},
+		{"10K_VDOT_50", 10000, 41*time.Minute + 21*time.Second, 50},
+		{"Half_VDOT_50", 21098, time.Hour + 31*time.Minute + 35*time.Second, 50},
+		{"Marathon_VDOT_50", 42195, 3*time.Hour + 10*time.Minute + 49*time.Second, 50},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := VDOT(test.distance, test.raceTime)
+			assert.Equal(t, math.Round(got*10)/10, test.want)
+		})
+	}
+}
+
+func TestVDOTTime(t *testing.T) {
+	// The published tables are rounded and differ from the formulas by up to
+	// ten seconds over a marathon.
+	tests := []struct {
+		name     string
+		vdot     float64
+		distance int
+		want     time.Duration
+	}{
+		{"5K_VDOT_50", 50, 5000, 19*time.Minute + 56*time.Second},
+		{"10K_VDOT_50", 50, 10000, 41*time.Minute + 20*time.Second},
+		{"Marathon_VDOT_50", 50, 42195, 3*time.Hour + 10*time.Minute + 40*time.Second},
+		{"Marathon_VDOT_40", 40, 42195, 3*time.Hour + 49*time.Minute + 37*time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := VDOTTime(test.vdot, test.distance)
+			assert.Equal(t, got.Round(time.Second), test.want)
+		})
+	}
+}
+
+func TestVDOTTimeInvertsVDOT(t *testing.T) {
+	for _, distance := range StandardDistances {
+		raceTime := VDOTTime(55.5, distance)
+		assert.Equal(t, math.Round(VDOT(distance, raceTime)*100)/100, 55.5)
+	}
+}
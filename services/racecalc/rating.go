@@ -0,0 +1,69 @@
+package racecalc
+
+import (
+	"math"
+	"time"
+)
+
+// InitialRating is the rating of a runner before their first race.
+const InitialRating = 1500.0
+
+// ratingK is how far a rating moves in a race against a single runner of
+// equal rating that ends close.
+const ratingK = 32.0
+
+// maxMarginWeight is the most the winning margin adds to the weight of a
+// pairing, reached at a margin of marginScale of the faster time.
+const (
+	maxMarginWeight = 0.5
+	marginScale     = 0.05
+)
+
+// Finisher is a runner in a race: their rating before it, their place, with
+// equal places for a tie, and their time, zero when unknown.
+type Finisher struct {
+	Rating float64
+	Place  int
+	Time   time.Duration
+}
+
+// RateRace returns the ratings of finishers after the race, in their order.
+// Every finisher is scored against every other like in an Elo game, won by
+// the better place, and the score is split across the field so a race moves
+// a rating about as far as a single game. The clearer the margin in time, the
+// more a pairing weighs. Points are exchanged, never created.
+func RateRace(finishers []Finisher) []float64 {
+	ratings := make([]float64, len(finishers))
+	for i, finisher := range finishers {
+		ratings[i] = finisher.Rating
+	}
+	if len(finishers) < 2 {
+		return ratings
+	}
+	games := float64(len(finishers) - 1)
+	for i, a := range finishers {
+		for j, b := range finishers {
+			if i == j {
+				continue
+			}
+			score := 0.5
+			if a.Place < b.Place {
+				score = 1
+			} else if a.Place > b.Place {
+				score = 0
+			}
+			expected := 1 / (1 + math.Pow(10, (b.Rating-a.Rating)/400))
+			ratings[i] += ratingK * marginWeight(a.Time, b.Time) *
+				(score - expected) / games
+		}
+	}
+	return ratings
+}
+
+func marginWeight(a, b time.Duration) float64 {
+	if a <= 0 || b <= 0 {
+		return 1
+	}
+	margin := float64((a - b).Abs()) / float64(min(a, b))
+	return 1 + maxMarginWeight*math.Min(margin/marginScale, 1)
+}
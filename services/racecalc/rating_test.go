@@ -0,0 +1,99 @@
+package racecalc
+
+import (
+	"github.com/magiconair/properties/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateRace(t *testing.T) {
+	minutes := func(n float64) time.Duration {
+		return time.Duration(n * float64(time.Minute))
+	}
+	tests := []struct {
+		name      string
+		finishers []Finisher
+		want      []float64
+	}{
+		{
+			name:      "Alone",
+			finishers: []Finisher{{1500, 1, minutes(150)}},
+			want:      []float64{1500},
+		},
+		{
+			// Equal ratings expect 0.5, the winner scores 1: 32 * 0.5.
+			name: "Close_Win",
+			finishers: []Finisher{
+				{1500, 1, minutes(150)},
+				{1500, 2, minutes(150)},
+			},
+			want: []float64{1516, 1484},
+		},
+		{
+			// A 5% margin weighs 1.5 times.
+			name: "Clear_Win",
+			finishers: []Finisher{
+				{1500, 1, minutes(100)},
+				{1500, 2, minutes(105)},
+			},
+			want: []float64{1524, 1476},
+		},
+		{
+			name: "Unknown_Times",
+			finishers: []Finisher{
+				{1500, 1, 0},
+				{1500, 2, minutes(105)},
+			},
+			want: []float64{1516, 1484},
+		},
+		{
+			name: "Tie",
+			finishers: []Finisher{
+				{1500, 1, minutes(150)},
+				{1500, 1, minutes(150)},
+			},
+			want: []float64{1500, 1500},
+		},
+		{
+			// The favourite expects 0.909 and wins: 32 * 0.091.
+			name: "Favourite_Wins",
+			finishers: []Finisher{
+				{1900, 1, minutes(150)},
+				{1500, 2, minutes(150)},
+			},
+			want: []float64{1902.91, 1497.09},
+		},
+		{
+			name: "Upset",
+			finishers: []Finisher{
+				{1500, 1, minutes(150)},
+				{1900, 2, minutes(150)},
+			},
+			want: []float64{1529.09, 1870.91},
+		},
+		{
+			// The middle runner beats one and loses to one of equal rating.
+			name: "Field_Of_Three",
+			finishers: []Finisher{
+				{1500, 1, minutes(150)},
+				{1500, 2, minutes(150)},
+				{1500, 3, minutes(150)},
+			},
+			want: []float64{1516, 1500, 1484},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := RateRace(test.finishers)
+			total, before := 0.0, 0.0
+			for i := range got {
+				got[i] = math.Round(got[i]*100) / 100
+				total += got[i]
+				before += test.finishers[i].Rating
+			}
+			assert.Equal(t, got, test.want)
+			assert.Equal(t, math.Round(total), before)
+		})
+	}
+}
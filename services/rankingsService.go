@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/fentezi/runnerBook/tracing"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RankingsService struct {
+	ratingsRepository *repositories.RatingsRepository
+	countriesService  *CountriesService
+	// updates serializes rating updates, which replay races in order.
+	updates *sync.Mutex
+}
+
+func NewRankingsService(
+	ratingsRepository *repositories.RatingsRepository,
+	countriesService *CountriesService) *RankingsService {
+	return &RankingsService{
+		ratingsRepository: ratingsRepository,
+		countriesService:  countriesService,
+		updates:           &sync.Mutex{},
+	}
+}
+
+// RankingsQuery holds the parameters of the rankings. Distance defaults to
+// the marathon and Date, "YYYY-MM-DD", to today.
+type RankingsQuery struct {
+	Distance string
+	Date     string
+	Country  string
+	Category string
+}
+
+// GetRankings ranks the runners by their rating over a distance as of a date,
+// which gives historical rankings for past dates.
+func (rs RankingsService) GetRankings(ctx context.Context,
+	query RankingsQuery) (*models.Rankings, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RankingsService.GetRankings")
+	defer span.End()
+	filter := repositories.RankingsFilter{
+		Distance: models.MarathonDistance,
+		Date:     time.Now().Format(dateLayout),
+		Country:  query.Country,
+		Category: strings.ToUpper(query.Category),
+	}
+	if query.Distance != "" {
+		distance, err := strconv.Atoi(query.Distance)
+		if err != nil || distance <= 0 {
+			return nil, &models.ResponseError{
+				Message: "Invalid distance",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		filter.Distance = distance
+	}
+	if query.Date != "" {
+		if _, err := time.Parse(dateLayout, query.Date); err != nil {
+			return nil, &models.ResponseError{
+				Message: "Invalid date",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		filter.Date = query.Date
+	}
+	if filter.Category != "" && !models.ValidCategory(filter.Category) {
+		return nil, &models.ResponseError{
+			Message: "Invalid category",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	var responseErr *models.ResponseError
+	filter.Country, responseErr = rs.countriesService.NormalizeCountry(ctx,
+		filter.Country)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	rankings, responseErr := rs.ratingsRepository.GetRankings(ctx, filter)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return &models.Rankings{
+		Distance: filter.Distance,
+		Date:     filter.Date,
+		Rankings: rankings,
+	}, nil
+}
+
+// UpdateRatings brings the ratings over distance up to date after a result
+// of year changed. Ratings before that year stand; the races from its start
+// on are replayed.
+func (rs RankingsService) UpdateRatings(ctx context.Context, distance,
+	year int) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "RankingsService.UpdateRatings")
+	defer span.End()
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Format(dateLayout)
+	return rs.replay(ctx, distance, from)
+}
+
+// RecomputeRatings recomputes all ratings from the first race on.
+func (rs RankingsService) RecomputeRatings(ctx context.Context) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "RankingsService.RecomputeRatings")
+	defer span.End()
+	distances, responseErr := rs.ratingsRepository.GetRaceDistances(ctx)
+	if responseErr != nil {
+		return responseErr
+	}
+	for _, distance := range distances {
+		responseErr = rs.replay(ctx, distance, "0001-01-01")
+		if responseErr != nil {
+			return responseErr
+		}
+	}
+	return nil
+}
+
+func (rs RankingsService) replay(ctx context.Context, distance int,
+	from string) *models.ResponseError {
+	rs.updates.Lock()
+	defer rs.updates.Unlock()
+	previous, responseErr := rs.ratingsRepository.GetRatingsBefore(ctx,
+		distance, from)
+	if responseErr != nil {
+		return responseErr
+	}
+	results, responseErr := rs.ratingsRepository.GetRaceResults(ctx,
+		distance, from)
+	if responseErr != nil {
+		return responseErr
+	}
+	ratings := rateRaces(previous, results)
+	return rs.ratingsRepository.ReplaceRatings(ctx, distance, from, ratings)
+}
+
+// rateRaces rates the races of results, ordered by race date, starting from
+// the previous ratings by runner ID. Results of a race share the location,
+// which is the race key, and the year. It returns the rating of every
+// finisher after every race.
+func rateRaces(previous map[string]*models.Rating,
+	results []*models.Result) []*models.Rating {
+	current := map[string]*models.Rating{}
+	for runnerID, rating := range previous {
+		current[runnerID] = rating
+	}
+	ratings := make([]*models.Rating, 0, len(results))
+	for start := 0; start < len(results); {
+		end := start + 1
+		for end < len(results) && results[end].Location == results[start].Location &&
+			results[end].Year == results[start].Year {
+			end++
+		}
+		race, places := finishers(results[start:end])
+		field := make([]racecalc.Finisher, 0, len(race))
+		for i, result := range race {
+			rating := racecalc.InitialRating
+			if previous := current[result.RunnerID]; previous != nil {
+				rating = previous.Rating
+			}
+			field = append(field, racecalc.Finisher{
+				Rating: rating,
+				Place:  places[i],
+				Time:   result.RaceResult.Duration(),
+			})
+		}
+		for i, value := range racecalc.RateRace(field) {
+			result := race[i]
+			rating := &models.Rating{
+				RunnerID: result.RunnerID,
+				Distance: result.Distance,
+				Race:     result.Location,
+				RaceDate: result.RaceDate,
+				Rating:   math.Round(value*100) / 100,
+				Races:    1,
+			}
+			if previous := current[result.RunnerID]; previous != nil {
+				rating.Races = previous.Races + 1
+			}
+			current[result.RunnerID] = rating
+			ratings = append(ratings, rating)
+		}
+		start = end
+	}
+	return ratings
+}
+
+// finishers orders the results of a race by position when every result has
+// one and by time otherwise, keeping the best result of a runner entered
+// twice, and returns their places. Finishers on the same position or time
+// share a place.
+func finishers(race []*models.Result) ([]*models.Result, []int) {
+	byPosition := true
+	for _, result := range race {
+		byPosition = byPosition && result.Position > 0
+	}
+	key := func(result *models.Result) int64 {
+		if byPosition {
+			return int64(result.Position)
+		}
+		return int64(result.RaceResult)
+	}
+	sorted := append([]*models.Result(nil), race...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) < key(sorted[j])
+	})
+	seen := map[string]bool{}
+	unique := make([]*models.Result, 0, len(sorted))
+	places := make([]int, 0, len(sorted))
+	for _, result := range sorted {
+		if seen[result.RunnerID] {
+			continue
+		}
+		seen[result.RunnerID] = true
+		place := len(unique) + 1
+		if last := len(unique) - 1; last >= 0 && key(unique[last]) == key(result) {
+			place = places[last]
+		}
+		unique = append(unique, result)
+		places = append(places, place)
+	}
+	return unique, places
+}
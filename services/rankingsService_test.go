@@ -0,0 +1,103 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestFinishers(t *testing.T) {
+	result := func(runnerID string, position, minutes int) *models.Result {
+		return &models.Result{
+			RunnerID:   runnerID,
+			Position:   position,
+			RaceResult: models.RaceTime(time.Duration(minutes) * time.Minute),
+		}
+	}
+	tests := []struct {
+		name       string
+		race       []*models.Result
+		wantOrder  []string
+		wantPlaces []int
+	}{
+		{
+			name:       "By_Position",
+			race:       []*models.Result{result("a", 2, 150), result("b", 1, 151), result("c", 3, 149)},
+			wantOrder:  []string{"b", "a", "c"},
+			wantPlaces: []int{1, 2, 3},
+		},
+		{
+			name:       "By_Time_Without_All_Positions",
+			race:       []*models.Result{result("a", 2, 150), result("b", 0, 151), result("c", 1, 149)},
+			wantOrder:  []string{"c", "a", "b"},
+			wantPlaces: []int{1, 2, 3},
+		},
+		{
+			name:       "Tied_Time",
+			race:       []*models.Result{result("a", 0, 150), result("b", 0, 145), result("c", 0, 150), result("d", 0, 155)},
+			wantOrder:  []string{"b", "a", "c", "d"},
+			wantPlaces: []int{1, 2, 2, 4},
+		},
+		{
+			name:       "Runner_Entered_Twice",
+			race:       []*models.Result{result("a", 0, 150), result("b", 0, 145), result("a", 0, 140)},
+			wantOrder:  []string{"a", "b"},
+			wantPlaces: []int{1, 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			race, places := finishers(test.race)
+			order := []string{}
+			for _, result := range race {
+				order = append(order, result.RunnerID)
+			}
+			assert.Equal(t, order, test.wantOrder)
+			assert.Equal(t, places, test.wantPlaces)
+		})
+	}
+}
+
+func TestRateRaces(t *testing.T) {
+	result := func(runnerID, race string, year, position int,
+		date string) *models.Result {
+		return &models.Result{
+			RunnerID:   runnerID,
+			Location:   race,
+			Year:       year,
+			RaceDate:   date,
+			Distance:   models.MarathonDistance,
+			Position:   position,
+			RaceResult: models.RaceTime(150 * time.Minute),
+		}
+	}
+	previous := map[string]*models.Rating{
+		"a": {RunnerID: "a", Rating: 1600, Races: 4},
+	}
+	results := []*models.Result{
+		result("b", "berlin", 2023, 1, "2023-09-24"),
+		result("c", "berlin", 2023, 2, "2023-09-24"),
+		result("a", "new york", 2023, 2, "2023-11-05"),
+		result("b", "new york", 2023, 1, "2023-11-05"),
+	}
+	ratings := rateRaces(previous, results)
+	type rating struct {
+		runnerID string
+		race     string
+		rating   float64
+		races    int
+	}
+	got := []rating{}
+	for _, r := range ratings {
+		got = append(got, rating{r.RunnerID, r.Race, r.Rating, r.Races})
+	}
+	// In New York b, rated 1516, beats a, rated 1600, who expects 0.619.
+	assert.Equal(t, got, []rating{
+		{"b", "berlin", 1516, 1},
+		{"c", "berlin", 1484, 1},
+		{"b", "new york", 1535.79, 2},
+		{"a", "new york", 1580.21, 5},
+	})
+	assert.Equal(t, previous["a"].Rating, 1600.0)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type RecordsService struct {
+	recordsRepository *repositories.RecordsRepository
+	countriesService  *CountriesService
+}
+
+func NewRecordsService(
+	recordsRepository *repositories.RecordsRepository,
+	countriesService *CountriesService) *RecordsService {
+	return &RecordsService{
+		recordsRepository: recordsRepository,
+		countriesService:  countriesService,
+	}
+}
+
+// RecordsQuery holds the parameters of the records listing. Scope is world
+// or national; empty fields select all records.
+type RecordsQuery struct {
+	Scope    string
+	Country  string
+	Category string
+	Distance string
+}
+
+// GetRecords returns the current records matching query, each with the
+// lineage of records it broke.
+func (rs RecordsService) GetRecords(ctx context.Context,
+	query RecordsQuery) ([]*models.Record, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RecordsService.GetRecords")
+	defer span.End()
+	filter := repositories.RecordsFilter{
+		Scope:    query.Scope,
+		Country:  query.Country,
+		Category: strings.ToUpper(query.Category),
+	}
+	if filter.Scope != "" && filter.Scope != models.ScopeWorld &&
+		filter.Scope != models.ScopeNational {
+		return nil, &models.ResponseError{
+			Message: "Invalid scope",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if filter.Scope == models.ScopeWorld && filter.Country != "" {
+		return nil, &models.ResponseError{
+			Message: "World records have no country",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if filter.Category != "" && !models.ValidCategory(filter.Category) {
+		return nil, &models.ResponseError{
+			Message: "Invalid category",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if query.Distance != "" {
+		distance, err := strconv.Atoi(query.Distance)
+		if err != nil || distance <= 0 {
+			return nil, &models.ResponseError{
+				Message: "Invalid distance",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		filter.Distance = distance
+	}
+	var responseErr *models.ResponseError
+	filter.Country, responseErr = rs.countriesService.NormalizeCountry(ctx,
+		filter.Country)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	records, responseErr := rs.recordsRepository.GetRecords(ctx, filter)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return recordLineages(records), nil
+}
+
+// recordScope is a country, empty for the world, and a category, empty for
+// all categories, records are kept for.
+type recordScope struct {
+	country  string
+	category string
+}
+
+// recordScopes returns the scopes a result of runner can set a record in.
+func recordScopes(runner *models.Runner) []recordScope {
+	countries := []string{""}
+	if runner.Country != "" {
+		countries = append(countries, runner.Country)
+	}
+	categories := []string{""}
+	if runner.Category != "" {
+		categories = append(categories, runner.Category)
+	}
+	scopes := make([]recordScope, 0, len(countries)*len(categories))
+	for _, country := range countries {
+		for _, category := range categories {
+			scopes = append(scopes, recordScope{country, category})
+		}
+	}
+	return scopes
+}
+
+// recordLineages returns the current records, in their order, with the
+// records each of them broke as its history.
+func recordLineages(records []*models.Record) []*models.Record {
+	byID := map[string]*models.Record{}
+	for _, record := range records {
+		byID[record.ID] = record
+	}
+	current := make([]*models.Record, 0)
+	for _, record := range records {
+		if !record.Current {
+			continue
+		}
+		for previous := byID[record.PreviousID]; previous != nil; previous = byID[previous.PreviousID] {
+			record.History = append(record.History, previous)
+		}
+		current = append(current, record)
+	}
+	return current
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestRecordScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		runner *models.Runner
+		want   []recordScope
+	}{
+		{
+			name:   "Country_And_Category",
+			runner: &models.Runner{Country: "Kenya", Category: "M35"},
+			want:   []recordScope{{"", ""}, {"", "M35"}, {"Kenya", ""}, {"Kenya", "M35"}},
+		},
+		{
+			name:   "Without_Category",
+			runner: &models.Runner{Country: "Kenya"},
+			want:   []recordScope{{"", ""}, {"Kenya", ""}},
+		},
+		{
+			name:   "Without_Country",
+			runner: &models.Runner{Category: "W40"},
+			want:   []recordScope{{"", ""}, {"", "W40"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, recordScopes(test.runner), test.want)
+		})
+	}
+}
+
+func TestRecordLineages(t *testing.T) {
+	records := []*models.Record{
+		{ID: "c", PreviousID: "b", Current: true},
+		{ID: "x", Current: true},
+		{ID: "b", PreviousID: "a"},
+		{ID: "a"},
+	}
+	current := recordLineages(records)
+	ids := func(records []*models.Record) []string {
+		ids := []string{}
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, ids(current), []string{"c", "x"})
+	assert.Equal(t, ids(current[0].History), []string{"b", "a"})
+	assert.Equal(t, ids(current[1].History), []string{})
+}
@@ -1,42 +1,90 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"github.com/fentezi/runnerBook/metrics"
 	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/fentezi/runnerBook/tracing"
+	"log/slog"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 )
 
+// maxPrecision is the finest precision results are recorded with, in digits
+// of a second.
+const maxPrecision = 3
+
+// maxNoteLength is the length of the longest result note, in bytes.
+const maxNoteLength = 2000
+
 type ResultsService struct {
 	resultsRepository *repositories.ResultsRepository
 	runnersRepository *repositories.RunnersRepository
+	recordsRepository *repositories.RecordsRepository
+	seasonCalendar    *SeasonCalendar
+	ageGrading        *racecalc.AgeGradingTable
+	rankingsService   *RankingsService
+	coachesService    *CoachesService
 }
 
 func NewResultsService(resultsRepository *repositories.ResultsRepository,
-	runnersRepository *repositories.RunnersRepository) *ResultsService {
+	runnersRepository *repositories.RunnersRepository,
+	recordsRepository *repositories.RecordsRepository,
+	seasonCalendar *SeasonCalendar,
+	ageGrading *racecalc.AgeGradingTable,
+	rankingsService *RankingsService,
+	coachesService *CoachesService) *ResultsService {
 	return &ResultsService{
 		resultsRepository: resultsRepository,
 		runnersRepository: runnersRepository,
+		recordsRepository: recordsRepository,
+		seasonCalendar:    seasonCalendar,
+		ageGrading:        ageGrading,
+		rankingsService:   rankingsService,
+		coachesService:    coachesService,
 	}
 }
 
-func (rs ResultsService) CreateResult(result *models.Result) (*models.Result, *models.ResponseError) {
+// CreateResult records a result submitted by user, an admin or a coach of
+// the runner.
+func (rs ResultsService) CreateResult(ctx context.Context, user *models.User,
+	result *models.Result) (*models.Result, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ResultsService.CreateResult")
+	defer span.End()
 	if result.RunnerID == "" {
 		return nil, &models.ResponseError{
 			Message: "Invalid runner ID",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	if result.RaceResult == "" {
+	responseErr := rs.coachesService.AuthorizeRunner(ctx, user, result.RunnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = validateTiming(result)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = validateStatus(result)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if result.Location == "" {
 		return nil, &models.ResponseError{
-			Message: "Invalid race result",
+			Message: "Invalid location",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	if result.Location == "" {
+	if result.Distance == 0 {
+		result.Distance = models.MarathonDistance
+	}
+	if result.Distance < 0 {
 		return nil, &models.ResponseError{
-			Message: "Invalid location",
+			Message: "Invalid distance",
 			Status:  http.StatusBadRequest,
 		}
 	}
@@ -46,124 +94,371 @@ func (rs ResultsService) CreateResult(result *models.Result) (*models.Result, *m
 			Status:  http.StatusBadRequest,
 		}
 	}
-	currentYear := time.Now().Year()
+	now := time.Now()
+	if result.RaceDate != "" {
+		date, err := time.Parse(dateLayout, result.RaceDate)
+		if err != nil || date.After(now) {
+			return nil, &models.ResponseError{
+				Message: "Invalid race date",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		if result.Year == 0 {
+			result.Year = date.Year()
+		}
+		if result.Year != date.Year() {
+			return nil, &models.ResponseError{
+				Message: "Race date does not match year",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	currentYear := now.Year()
 	if result.Year < 0 || result.Year > currentYear {
 		return nil, &models.ResponseError{
 			Message: "Invalid year",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	raceResult, err := parseRaceResult(result.RaceResult)
+	transaction, err := repositories.BeginTransaction(ctx,
+		rs.resultsRepository)
 	if err != nil {
 		return nil, &models.ResponseError{
-			Message: "Invalid race result",
-			Status:  http.StatusBadRequest,
+			Message: "Failed to start transaction",
+			Status:  http.StatusInternalServerError,
 		}
 	}
-	response, responseErr := rs.resultsRepository.CreateResult(result)
+	response, responseErr := rs.resultsRepository.CreateResult(ctx, transaction,
+		result)
 	if responseErr != nil {
+		transaction.Rollback()
 		return nil, responseErr
 	}
-	runner, responseErr := rs.runnersRepository.GetRunner(result.RunnerID)
+	runner, responseErr := rs.runnersRepository.GetRunner(ctx, result.RunnerID)
 	if responseErr != nil {
+		transaction.Rollback()
 		return nil, responseErr
 	}
 	if runner == nil {
+		transaction.Rollback()
 		return nil, &models.ResponseError{
 			Message: "Runner not found",
 			Status:  http.StatusNotFound,
 		}
 	}
-	if runner.PersonalBest == "" {
+	// Only marathons are compared, times over other distances would always
+	// beat them.
+	finished := result.Status == models.StatusFinished &&
+		result.Distance == models.MarathonDistance
+	if finished &&
+		(runner.PersonalBest == 0 || result.RaceResult < runner.PersonalBest) {
 		runner.PersonalBest = result.RaceResult
-	} else {
-		personalBest, err := parseRaceResult(runner.PersonalBest)
-		if err != nil {
-			return nil, &models.ResponseError{
-				Message: "Failed to parser " + "personal best",
-				Status:  http.StatusInternalServerError,
-			}
-		}
-		if raceResult < personalBest {
-			runner.PersonalBest = strconv.FormatInt(int64(personalBest), 10)
-		}
+		metrics.PersonalBestsSet.Inc()
 	}
-	if result.Year == currentYear {
-		if runner.SeasonBest == "" {
-			runner.SeasonBest = result.RaceResult
-		} else {
-			seasonBest, err := parseRaceResult(runner.SeasonBest)
-			if err != nil {
-				return nil, &models.ResponseError{
-					Message: "Failed to parse " + "season best",
-					Status:  http.StatusInternalServerError,
-				}
-			}
-			if raceResult < seasonBest {
-				runner.SeasonBest = result.RaceResult
-			}
-		}
-
+	if finished && rs.seasonCalendar.Contains(now, result.RaceDate, result.Year) &&
+		(runner.SeasonBest == 0 || result.RaceResult < runner.SeasonBest) {
+		runner.SeasonBest = result.RaceResult
+		metrics.SeasonBestsSet.Inc()
 	}
-	responseErr = rs.runnersRepository.UpdateRunnerResults(runner)
+	responseErr = rs.runnersRepository.UpdateRunnerResults(ctx, transaction, runner)
 	if responseErr != nil {
+		transaction.Rollback()
 		return nil, responseErr
 	}
+	if result.Status == models.StatusFinished {
+		response.Records, responseErr = rs.updateRecords(ctx, transaction,
+			runner, response)
+		if responseErr != nil {
+			transaction.Rollback()
+			return nil, responseErr
+		}
+	}
+	err = transaction.Commit()
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: "Failed to commit transaction",
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	metrics.ResultsCreated.Inc()
+	metrics.RecordsSet.Add(float64(len(response.Records)))
+	if result.Status == models.StatusFinished {
+		rs.updateRatings(ctx, result)
+	}
+	gradeResult(rs.ageGrading, runner, response)
 	return response, nil
 }
 
-func (rs ResultsService) DeleteResult(resultID string) *models.ResponseError {
+func (rs ResultsService) DeleteResult(ctx context.Context, resultID string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "ResultsService.DeleteResult")
+	defer span.End()
 	if resultID == "" {
 		return &models.ResponseError{
 			Message: "Invalid result ID",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	err := repositories.BeginTransaction(
-		rs.runnersRepository, rs.resultsRepository)
+	transaction, err := repositories.BeginTransaction(ctx,
+		rs.resultsRepository)
 	if err != nil {
 		return &models.ResponseError{
 			Message: "Failed to start transaction",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	result, responseErr := rs.resultsRepository.DeleteResult(resultID)
+	responseErr := rs.recordsRepository.DeleteResultRecords(ctx, transaction,
+		resultID)
 	if responseErr != nil {
+		transaction.Rollback()
 		return responseErr
 	}
-	runner, responseErr := rs.runnersRepository.GetRunner(result.RunnerID)
+	result, responseErr := rs.resultsRepository.DeleteResult(ctx, transaction,
+		resultID)
 	if responseErr != nil {
-		repositories.RollbackTransaction(rs.runnersRepository, rs.resultsRepository)
+		transaction.Rollback()
+		return responseErr
+	}
+	runner, responseErr := rs.runnersRepository.GetRunner(ctx, result.RunnerID)
+	if responseErr != nil {
+		transaction.Rollback()
 		return responseErr
 	}
 	if runner.PersonalBest == result.RaceResult {
-		personalBest, responseErr := rs.resultsRepository.GetPersonalBestResults(result.RunnerID)
+		personalBest, responseErr := rs.resultsRepository.GetPersonalBestResults(ctx,
+			transaction, result.RunnerID)
 		if responseErr != nil {
-			repositories.RollbackTransaction(rs.runnersRepository, rs.resultsRepository)
+			transaction.Rollback()
 			return responseErr
 		}
 		runner.PersonalBest = personalBest
 	}
-	currentYear := time.Now().Year()
-	if runner.SeasonBest == result.RaceResult && result.Year == currentYear {
-		seasonBest, responseErr := rs.resultsRepository.GetSeasonBestResults(result.RunnerID, result.Year)
+	now := time.Now()
+	if runner.SeasonBest == result.RaceResult &&
+		rs.seasonCalendar.Contains(now, result.RaceDate, result.Year) {
+		seasonStart, seasonEnd := rs.seasonCalendar.SeasonDates(now)
+		seasonBest, responseErr := rs.resultsRepository.GetSeasonBestResults(ctx,
+			transaction, result.RunnerID, seasonStart, seasonEnd)
 		if responseErr != nil {
-			repositories.RollbackTransaction(rs.runnersRepository, rs.resultsRepository)
+			transaction.Rollback()
 			return responseErr
 		}
 		runner.SeasonBest = seasonBest
 	}
-	responseErr = rs.runnersRepository.UpdateRunnerResults(runner)
+	responseErr = rs.runnersRepository.UpdateRunnerResults(ctx, transaction, runner)
 	if responseErr != nil {
-		repositories.RollbackTransaction(rs.runnersRepository, rs.resultsRepository)
+		transaction.Rollback()
 		return responseErr
 	}
-	repositories.CommitTransaction(rs.runnersRepository, rs.resultsRepository)
+	err = transaction.Commit()
+	if err != nil {
+		return &models.ResponseError{
+			Message: "Failed to commit transaction",
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	if result.Status == models.StatusFinished {
+		rs.updateRatings(ctx, result)
+	}
 	return nil
 }
 
-func parseRaceResult(timeString string) (time.Duration, error) {
-	return time.ParseDuration(timeString[0:2] + "h" +
-		timeString[3:5] + "m" +
-		timeString[6:8] + "s")
+// AddResultNote annotates a result on behalf of user, an admin or a coach of
+// the runner.
+func (rs ResultsService) AddResultNote(ctx context.Context, user *models.User,
+	note *models.ResultNote) (*models.ResultNote, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ResultsService.AddResultNote")
+	defer span.End()
+	responseErr := validateNote(note.Note)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = rs.authorizeResult(ctx, user, note.ResultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	note.Author = user.Username
+	return rs.resultsRepository.CreateResultNote(ctx, note, user.ID)
+}
+
+// GetResultNotes returns the notes of a result to user, an admin or a coach
+// of the runner.
+func (rs ResultsService) GetResultNotes(ctx context.Context, user *models.User,
+	resultID string) ([]*models.ResultNote, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "ResultsService.GetResultNotes")
+	defer span.End()
+	responseErr := rs.authorizeResult(ctx, user, resultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return rs.resultsRepository.GetResultNotes(ctx, resultID)
+}
+
+// authorizeResult checks that user may act for the runner of a result.
+func (rs ResultsService) authorizeResult(ctx context.Context, user *models.User,
+	resultID string) *models.ResponseError {
+	if resultID == "" {
+		return &models.ResponseError{
+			Message: "Invalid result ID",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	result, responseErr := rs.resultsRepository.GetResult(ctx, resultID)
+	if responseErr != nil {
+		return responseErr
+	}
+	if result == nil {
+		return &models.ResponseError{
+			Message: "Result not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	return rs.coachesService.AuthorizeRunner(ctx, user, result.RunnerID)
+}
+
+// updateRecords records the records a new finished result of runner breaks,
+// in every scope it counts in, within the transaction creating the result.
+func (rs ResultsService) updateRecords(ctx context.Context, transaction *sql.Tx,
+	runner *models.Runner, result *models.Result) ([]*models.Record, *models.ResponseError) {
+	var records []*models.Record
+	for _, scope := range recordScopes(runner) {
+		current, responseErr := rs.recordsRepository.GetCurrentRecord(ctx,
+			transaction, scope.country, scope.category, result.Distance)
+		if responseErr != nil {
+			return nil, responseErr
+		}
+		if current != nil && current.Time <= result.RaceResult {
+			continue
+		}
+		record := &models.Record{
+			Country:    scope.country,
+			Category:   scope.category,
+			Distance:   result.Distance,
+			Time:       result.RaceResult,
+			ResultID:   result.ID,
+			RunnerID:   runner.ID,
+			RunnerName: runner.FirstName + " " + runner.LastName,
+			Location:   result.Location,
+			RaceDate:   resultDate(result).Format(dateLayout),
+		}
+		previousID := ""
+		if current != nil {
+			previousID = current.ID
+		}
+		responseErr = rs.recordsRepository.CreateRecord(ctx, transaction,
+			record, previousID)
+		if responseErr != nil {
+			return nil, responseErr
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// updateRatings updates the ratings after a finished result was added or
+// deleted. The result stands when that fails; the ratings catch up with the
+// next update or recompute.
+func (rs ResultsService) updateRatings(ctx context.Context, result *models.Result) {
+	responseErr := rs.rankingsService.UpdateRatings(ctx, result.Distance, result.Year)
+	if responseErr != nil {
+		slog.ErrorContext(ctx, "Updating ratings failed", "result_id", result.ID,
+			"error", responseErr.Message)
+	}
+}
+
+// validateTiming sets the race result to the chip time, or to the gun time
+// when there is no chip time, and checks the precision the times are
+// recorded with. Without a precision, the precision of the times is used.
+func validateTiming(result *models.Result) *models.ResponseError {
+	if result.ChipTime > 0 && result.GunTime > 0 && result.ChipTime > result.GunTime {
+		return &models.ResponseError{
+			Message: "Chip time cannot exceed gun time",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	effective := result.ChipTime
+	if effective == 0 {
+		effective = result.GunTime
+	}
+	if effective > 0 {
+		if result.RaceResult > 0 && result.RaceResult != effective {
+			return &models.ResponseError{
+				Message: "Race result must match the chip time, or the gun time without chip time",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		result.RaceResult = effective
+	}
+	precision := max(result.RaceResult.Precision(),
+		result.GunTime.Precision(), result.ChipTime.Precision())
+	if result.Precision == 0 {
+		result.Precision = precision
+	}
+	if result.Precision < precision || result.Precision > maxPrecision {
+		return &models.ResponseError{
+			Message: "Invalid precision",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+// validateStatus defaults the status to finished and checks that the result
+// has a time and place only when the status allows them. Disqualified
+// results may keep the time they were run in.
+func validateStatus(result *models.Result) *models.ResponseError {
+	if result.Status == "" {
+		result.Status = models.StatusFinished
+	}
+	switch result.Status {
+	case models.StatusFinished:
+		if result.RaceResult <= 0 {
+			return &models.ResponseError{
+				Message: "Invalid race result",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		if result.StatusReason != "" {
+			return &models.ResponseError{
+				Message: "Status reason is only allowed for non-finishing results",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		return nil
+	case models.StatusDidNotFinish, models.StatusDidNotStart:
+		if result.RaceResult > 0 {
+			return &models.ResponseError{
+				Message: "Race result is not allowed for " + result.Status + " results",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	case models.StatusDisqualified:
+	default:
+		return &models.ResponseError{
+			Message: "Invalid status",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if result.Position > 0 {
+		return &models.ResponseError{
+			Message: "Position is only allowed for finished results",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+func validateNote(note string) *models.ResponseError {
+	if strings.TrimSpace(note) == "" {
+		return &models.ResponseError{
+			Message: "Invalid note",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if len(note) > maxNoteLength {
+		return &models.ResponseError{
+			Message: "Note is too long",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
 }
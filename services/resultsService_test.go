@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/magiconair/properties/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTiming(t *testing.T) {
+	const (
+		gun  = models.RaceTime(2*time.Hour + 5*time.Minute + 30*time.Second)
+		chip = models.RaceTime(2*time.Hour + 4*time.Minute + 58*time.Second)
+		fast = models.RaceTime(9*time.Second + 580*time.Millisecond)
+	)
+	tests := []struct {
+		name          string
+		result        models.Result
+		wantResult    models.RaceTime
+		wantPrecision int
+		wantErr       string
+	}{
+		{
+			name:       "Race_Result_Only",
+			result:     models.Result{RaceResult: gun},
+			wantResult: gun,
+		},
+		{
+			name:       "Chip_Time_Preferred",
+			result:     models.Result{GunTime: gun, ChipTime: chip},
+			wantResult: chip,
+		},
+		{
+			name:       "Gun_Time_Fallback",
+			result:     models.Result{GunTime: gun},
+			wantResult: gun,
+		},
+		{
+			name:          "Precision_From_Times",
+			result:        models.Result{GunTime: fast},
+			wantResult:    fast,
+			wantPrecision: 2,
+		},
+		{
+			name:          "Explicit_Precision",
+			result:        models.Result{GunTime: fast, Precision: 3},
+			wantResult:    fast,
+			wantPrecision: 3,
+		},
+		{
+			name:    "Chip_Slower_Than_Gun",
+			result:  models.Result{GunTime: chip, ChipTime: gun},
+			wantErr: "Chip time cannot exceed gun time",
+		},
+		{
+			name:    "Race_Result_Mismatch",
+			result:  models.Result{RaceResult: gun, GunTime: gun, ChipTime: chip},
+			wantErr: "Race result must match the chip time, or the gun time without chip time",
+		},
+		{
+			name:    "Precision_Too_Coarse",
+			result:  models.Result{GunTime: fast, Precision: 1},
+			wantErr: "Invalid precision",
+		},
+		{
+			name:    "Precision_Too_Fine",
+			result:  models.Result{RaceResult: gun + 1},
+			wantErr: "Invalid precision",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.result
+			responseErr := validateTiming(&result)
+			if test.wantErr != "" {
+				assert.Equal(t, true, responseErr != nil)
+				assert.Equal(t, test.wantErr, responseErr.Message)
+				return
+			}
+			assert.Equal(t, true, responseErr == nil)
+			assert.Equal(t, test.wantResult, result.RaceResult)
+			assert.Equal(t, test.wantPrecision, result.Precision)
+		})
+	}
+}
+
+func TestValidateStatus(t *testing.T) {
+	raceResult := models.RaceTime(2 * time.Hour)
+	tests := []struct {
+		name       string
+		result     models.Result
+		wantStatus string
+		wantErr    string
+	}{
+		{
+			name:       "Finished_By_Default",
+			result:     models.Result{RaceResult: raceResult, Position: 3},
+			wantStatus: models.StatusFinished,
+		},
+		{
+			name:    "Finished_Without_Time",
+			result:  models.Result{Status: models.StatusFinished},
+			wantErr: "Invalid race result",
+		},
+		{
+			name:    "Finished_With_Reason",
+			result:  models.Result{RaceResult: raceResult, StatusReason: "Injury"},
+			wantErr: "Status reason is only allowed for non-finishing results",
+		},
+		{
+			name:       "Did_Not_Finish",
+			result:     models.Result{Status: models.StatusDidNotFinish, StatusReason: "Injury"},
+			wantStatus: models.StatusDidNotFinish,
+		},
+		{
+			name:    "Did_Not_Start_With_Time",
+			result:  models.Result{Status: models.StatusDidNotStart, RaceResult: raceResult},
+			wantErr: "Race result is not allowed for DNS results",
+		},
+		{
+			name:       "Disqualified_With_Time",
+			result:     models.Result{Status: models.StatusDisqualified, RaceResult: raceResult},
+			wantStatus: models.StatusDisqualified,
+		},
+		{
+			name:    "Disqualified_With_Position",
+			result:  models.Result{Status: models.StatusDisqualified, Position: 1},
+			wantErr: "Position is only allowed for finished results",
+		},
+		{
+			name:    "Unknown_Status",
+			result:  models.Result{Status: "RET"},
+			wantErr: "Invalid status",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.result
+			responseErr := validateStatus(&result)
+			if test.wantErr != "" {
+				assert.Equal(t, true, responseErr != nil)
+				assert.Equal(t, test.wantErr, responseErr.Message)
+				return
+			}
+			assert.Equal(t, true, responseErr == nil)
+			assert.Equal(t, test.wantStatus, result.Status)
+		})
+	}
+}
+
+func TestValidateNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		note    string
+		wantErr string
+	}{
+		{name: "Valid", note: "Went out too fast, faded after 30k"},
+		{name: "Empty", note: "", wantErr: "Invalid note"},
+		{name: "Blank", note: " \n\t", wantErr: "Invalid note"},
+		{name: "Too_Long", note: strings.Repeat("a", maxNoteLength+1), wantErr: "Note is too long"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			responseErr := validateNote(test.note)
+			if test.wantErr != "" {
+				assert.Equal(t, true, responseErr != nil)
+				assert.Equal(t, test.wantErr, responseErr.Message)
+				return
+			}
+			assert.Equal(t, true, responseErr == nil)
+		})
+	}
+}
+
+func TestDeleteResultLowersPersonalBest(t *testing.T) {
+	dbHandler, mock, _ := sqlmock.New()
+	defer dbHandler.Close()
+	const (
+		deleted    = models.RaceTime(2*time.Hour + 5*time.Minute)
+		nextBest   = models.RaceTime(2*time.Hour + 7*time.Minute + 30*time.Second)
+		seasonBest = models.RaceTime(2*time.Hour + 10*time.Minute)
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM records").WithArgs("r1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("DELETE FROM results").WithArgs("r1").
+		WillReturnRows(sqlmock.NewRows([]string{"runner_id", "race_result",
+			"year", "race_date", "status", "distance"}).
+			AddRow("1", deleted.String(), 2020, time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+				models.StatusFinished, models.MarathonDistance))
+	mock.ExpectQuery("SELECT (.+) FROM runners").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name",
+			"age", "is_active", "country", "personal_best", "season_best",
+			"category", "date_of_birth"}).
+			AddRow("1", "John", "Smith", 30, true, "USA", deleted.String(),
+				seasonBest.String(), "M", nil))
+	mock.ExpectQuery(`SELECT MIN\(race_result\)`).WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nextBest.String()))
+	mock.ExpectExec("UPDATE runners").
+		WithArgs(nextBest.String(), seasonBest.String(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	seasonCalendar, _ := NewSeasonCalendar("UTC", "01-01")
+	resultsService := NewResultsService(
+		repositories.NewResultsRepository(dbHandler),
+		repositories.NewRunnersRepository(dbHandler),
+		repositories.NewRecordsRepository(dbHandler), seasonCalendar,
+		racecalc.DefaultAgeGradingTable(),
+		NewRankingsService(repositories.NewRatingsRepository(dbHandler), nil),
+		nil)
+	responseErr := resultsService.DeleteResult(context.Background(), "r1")
+	assert.Equal(t, responseErr == nil, true)
+	assert.Equal(t, mock.ExpectationsWereMet(), nil)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+	"slices"
+)
+
+type RolesService struct {
+	rolesRepository *repositories.RolesRepository
+}
+
+func NewRolesService(rolesRepository *repositories.RolesRepository) *RolesService {
+	return &RolesService{rolesRepository: rolesRepository}
+}
+
+func (rs RolesService) GetPermissions(ctx context.Context) ([]*models.Permission, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RolesService.GetPermissions")
+	defer span.End()
+	return rs.rolesRepository.GetPermissions(ctx)
+}
+
+func (rs RolesService) GetRoles(ctx context.Context) ([]*models.Role, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RolesService.GetRoles")
+	defer span.End()
+	return rs.rolesRepository.GetRoles(ctx)
+}
+
+func (rs RolesService) CreateRole(ctx context.Context,
+	role *models.Role) (*models.Role, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RolesService.CreateRole")
+	defer span.End()
+	responseErr := rs.validateRole(ctx, role)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	existing, responseErr := rs.rolesRepository.GetRole(ctx, role.Name)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if existing != nil {
+		return nil, &models.ResponseError{
+			Message: "Role already exists",
+			Status:  http.StatusConflict,
+		}
+	}
+	responseErr = rs.rolesRepository.CreateRole(ctx, role)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return role, nil
+}
+
+// UpdateRole replaces the permissions of an existing role.
+func (rs RolesService) UpdateRole(ctx context.Context,
+	role *models.Role) (*models.Role, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RolesService.UpdateRole")
+	defer span.End()
+	responseErr := rs.validateRole(ctx, role)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	existing, responseErr := rs.rolesRepository.GetRole(ctx, role.Name)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if existing == nil {
+		return nil, &models.ResponseError{
+			Message: "Role not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	responseErr = rs.rolesRepository.SetRolePermissions(ctx, role)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return role, nil
+}
+
+// DeleteRole deletes a role no user has. The admin role cannot be deleted.
+func (rs RolesService) DeleteRole(ctx context.Context,
+	name string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "RolesService.DeleteRole")
+	defer span.End()
+	if name == models.RoleAdmin {
+		return &models.ResponseError{
+			Message: "The admin role cannot be deleted",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	users, responseErr := rs.rolesRepository.CountUsers(ctx, name)
+	if responseErr != nil {
+		return responseErr
+	}
+	if users > 0 {
+		return &models.ResponseError{
+			Message: "Role is in use",
+			Status:  http.StatusConflict,
+		}
+	}
+	return rs.rolesRepository.DeleteRole(ctx, name)
+}
+
+func (rs RolesService) validateRole(ctx context.Context,
+	role *models.Role) *models.ResponseError {
+	permissions, responseErr := rs.rolesRepository.GetPermissions(ctx)
+	if responseErr != nil {
+		return responseErr
+	}
+	known := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		known = append(known, permission.Name)
+	}
+	return validateRolePermissions(role, known)
+}
+
+// validateRolePermissions checks the name of role and that it only grants
+// known permissions, which it sorts and deduplicates. The admin role must
+// keep models.PermissionUserManage, or nobody could edit roles any more.
+func validateRolePermissions(role *models.Role, known []string) *models.ResponseError {
+	responseErr := validateRole(role.Name)
+	if responseErr != nil {
+		return responseErr
+	}
+	for _, permission := range role.Permissions {
+		if !slices.Contains(known, permission) {
+			return &models.ResponseError{
+				Message: "Unknown permission " + permission,
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	if role.Name == models.RoleAdmin &&
+		!slices.Contains(role.Permissions, models.PermissionUserManage) {
+		return &models.ResponseError{
+			Message: "The admin role must keep " + models.PermissionUserManage,
+			Status:  http.StatusBadRequest,
+		}
+	}
+	slices.Sort(role.Permissions)
+	role.Permissions = slices.Compact(role.Permissions)
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestValidateRolePermissions(t *testing.T) {
+	known := []string{models.PermissionRunnerRead,
+		models.PermissionResultWrite, models.PermissionUserManage}
+	tests := []struct {
+		name        string
+		role        models.Role
+		want        []string
+		wantMessage string
+	}{
+		{"sorted and deduplicated", models.Role{Name: "editor",
+			Permissions: []string{models.PermissionResultWrite,
+				models.PermissionRunnerRead, models.PermissionResultWrite}},
+			[]string{models.PermissionResultWrite, models.PermissionRunnerRead}, ""},
+		{"no permissions", models.Role{Name: "guest"}, []string{}, ""},
+		{"unknown permission", models.Role{Name: "editor",
+			Permissions: []string{"result:approve"}},
+			nil, "Unknown permission result:approve"},
+		{"invalid name", models.Role{Name: "Editor"}, nil, "Invalid role"},
+		{"admin without user:manage", models.Role{Name: models.RoleAdmin,
+			Permissions: []string{models.PermissionRunnerRead}},
+			nil, "The admin role must keep user:manage"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			responseErr := validateRolePermissions(&test.role, known)
+			if test.wantMessage != "" {
+				assert.Equal(t, responseErr != nil, true)
+				assert.Equal(t, responseErr.Message, test.wantMessage)
+				return
+			}
+			assert.Equal(t, responseErr == nil, true)
+			assert.Equal(t, test.role.Permissions, test.want)
+		})
+	}
+}
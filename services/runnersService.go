@@ -1,38 +1,60 @@
 package services
 
 import (
+	"context"
 	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/services/racecalc"
+	"github.com/fentezi/runnerBook/tracing"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type RunnersService struct {
 	runnersRepository *repositories.RunnersRepository
 	resultsRepository *repositories.ResultsRepository
+	seasonCalendar    *SeasonCalendar
+	ageGrading        *racecalc.AgeGradingTable
+	countriesService  *CountriesService
 }
 
 func NewRunnersService(
 	runnersRepository *repositories.RunnersRepository,
-	resultsRepository *repositories.ResultsRepository) *RunnersService {
+	resultsRepository *repositories.ResultsRepository,
+	seasonCalendar *SeasonCalendar,
+	ageGrading *racecalc.AgeGradingTable,
+	countriesService *CountriesService) *RunnersService {
 	return &RunnersService{
 		runnersRepository: runnersRepository,
 		resultsRepository: resultsRepository,
+		seasonCalendar:    seasonCalendar,
+		ageGrading:        ageGrading,
+		countriesService:  countriesService,
 	}
 }
 
-func (rs RunnersService) CreateRunner(
+func (rs RunnersService) CreateRunner(ctx context.Context,
 	runner *models.Runner) (*models.Runner, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.CreateRunner")
+	defer span.End()
 	responseErr := validateRunner(runner)
 	if responseErr != nil {
 		return nil, responseErr
 	}
-	return rs.runnersRepository.CreateRunner(runner)
+	runner.Country, responseErr = rs.countriesService.NormalizeCountry(ctx,
+		runner.Country)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return rs.runnersRepository.CreateRunner(ctx, runner)
 }
 
-func (rs RunnersService) UpdateRunner(
+func (rs RunnersService) UpdateRunner(ctx context.Context,
 	runner *models.Runner) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.UpdateRunner")
+	defer span.End()
 	responseErr := validateRunnerID(runner.ID)
 	if responseErr != nil {
 		return responseErr
@@ -41,65 +63,261 @@ func (rs RunnersService) UpdateRunner(
 	if responseErr != nil {
 		return responseErr
 	}
-	return rs.runnersRepository.UpdateRunner(runner)
+	runner.Country, responseErr = rs.countriesService.NormalizeCountry(ctx,
+		runner.Country)
+	if responseErr != nil {
+		return responseErr
+	}
+	return rs.runnersRepository.UpdateRunner(ctx, runner)
 }
 
-func (rs RunnersService) DeleteRunner(
+func (rs RunnersService) DeleteRunner(ctx context.Context,
 	runnerID string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.DeleteRunner")
+	defer span.End()
 	responseErr := validateRunnerID(runnerID)
 	if responseErr != nil {
 		return responseErr
 	}
-	return rs.runnersRepository.DeleteRunner(runnerID)
+	return rs.runnersRepository.DeleteRunner(ctx, runnerID)
 }
 
-func (rs RunnersService) GetRunner(
+func (rs RunnersService) GetRunner(ctx context.Context,
 	runnerID string) (*models.Runner, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.GetRunner")
+	defer span.End()
 	responseErr := validateRunnerID(runnerID)
 	if responseErr != nil {
 		return nil, responseErr
 	}
-	runner, responseErr := rs.runnersRepository.GetRunner(runnerID)
+	runner, responseErr := rs.runnersRepository.GetRunner(ctx, runnerID)
 	if responseErr != nil {
 		return nil, responseErr
 	}
-	results, responseErr := rs.resultsRepository.GetAllRunnersResults(runnerID)
+	results, responseErr := rs.resultsRepository.GetAllRunnersResults(ctx, runnerID)
 	if responseErr != nil {
 		return nil, responseErr
 	}
+	for _, result := range results {
+		gradeResult(rs.ageGrading, runner, result)
+	}
 	runner.Results = results
 	return runner, nil
 }
 
-func (rs RunnersService) GetRunnersBatch(
-	country, year string) ([]*models.Runner, *models.ResponseError) {
-	if country != "" && year != "" {
+// CompareRunners compares runners runnerA and runnerB: their bests and the
+// races both of them finished.
+func (rs RunnersService) CompareRunners(ctx context.Context,
+	runnerA, runnerB string) (*models.Comparison, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.CompareRunners")
+	defer span.End()
+	if runnerA == "" || runnerB == "" {
+		return nil, &models.ResponseError{
+			Message: "Two runners are needed",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if runnerA == runnerB {
+		return nil, &models.ResponseError{
+			Message: "Cannot compare a runner with themselves",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	runners := make([]*models.Runner, 0, 2)
+	for _, runnerID := range []string{runnerA, runnerB} {
+		runner, responseErr := rs.GetRunner(ctx, runnerID)
+		if responseErr != nil {
+			return nil, responseErr
+		}
+		if runner.ID == "" {
+			return nil, &models.ResponseError{
+				Message: "Runner not found",
+				Status:  http.StatusNotFound,
+			}
+		}
+		runners = append(runners, runner)
+	}
+	return compareRunners(runners[0], runners[1]), nil
+}
+
+// RunnersBatchQuery holds the parameters of the runners listing.
+type RunnersBatchQuery struct {
+	Country  string
+	Year     string
+	Category string
+	AgeGroup string
+	Ranking  string
+}
+
+// GetRunnersBatch returns the leaderboard of a country, given by code, name or
+// alias, or of a year, or all runners when neither is given. Leaderboards can
+// be narrowed to a category and to an age group, such as M40, as of the race
+// date. Ranked by age grade, runners are compared over all distances and a
+// leaderboard of every runner is available too.
+func (rs RunnersService) GetRunnersBatch(ctx context.Context,
+	query RunnersBatchQuery) ([]*models.Runner, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.GetRunnersBatch")
+	defer span.End()
+	if query.Country != "" && query.Year != "" {
 		return nil, &models.ResponseError{
 			Message: "Only one parameter can be passed",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	if country != "" {
-		return rs.runnersRepository.GetRunnersByCountry(country)
+	filter, responseErr := leaderboardFilter(query.Category, query.AgeGroup)
+	if responseErr != nil {
+		return nil, responseErr
 	}
-	if year != "" {
-		intYear, err := strconv.Atoi(year)
-		if err != nil {
+	query.Country, responseErr = rs.countriesService.NormalizeCountry(ctx,
+		query.Country)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	year := 0
+	if query.Year != "" {
+		var err error
+		year, err = strconv.Atoi(query.Year)
+		if err != nil || year < 0 || year > time.Now().Year() {
 			return nil, &models.ResponseError{
 				Message: "Invalid year",
 				Status:  http.StatusBadRequest,
 			}
 		}
-		currentYear := time.Now().Year()
-		if intYear < 0 || intYear > currentYear {
+	}
+	switch query.Ranking {
+	case "", RankingTime:
+	case RankingAgeGraded:
+		runners, responseErr := rs.runnersRepository.GetRankingResults(ctx,
+			query.Country, year, filter)
+		if responseErr != nil {
+			return nil, responseErr
+		}
+		return rankByAgeGrade(rs.ageGrading, runners), nil
+	default:
+		return nil, &models.ResponseError{
+			Message: "Invalid ranking",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if query.Country != "" {
+		return rs.runnersRepository.GetRunnersByCountry(ctx, query.Country, filter)
+	}
+	if query.Year != "" {
+		return rs.runnersRepository.GetRunnersByYear(ctx, year, filter)
+	}
+	if filter != (repositories.LeaderboardFilter{}) {
+		return nil, &models.ResponseError{
+			Message: "Category and age group need a country or year",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return rs.runnersRepository.GetAllRunners(ctx)
+}
+
+func leaderboardFilter(category,
+	ageGroup string) (repositories.LeaderboardFilter, *models.ResponseError) {
+	filter := repositories.LeaderboardFilter{Category: strings.ToUpper(category)}
+	if filter.Category != "" && !models.ValidCategory(filter.Category) {
+		return filter, &models.ResponseError{
+			Message: "Invalid category",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if ageGroup == "" {
+		return filter, nil
+	}
+	groupCategory, minAge, maxAge, err := models.ParseAgeGroup(strings.ToUpper(ageGroup))
+	if err != nil {
+		return filter, &models.ResponseError{
+			Message: "Invalid age group",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if filter.Category != "" && filter.Category != groupCategory {
+		return filter, &models.ResponseError{
+			Message: "Category does not match age group",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	filter.Category, filter.MinAge, filter.MaxAge = groupCategory, minAge, maxAge
+	return filter, nil
+}
+
+// RecomputeBests finds the runners whose stored personal or season best has
+// drifted from their results, for one runner or, when runnerID is empty, for
+// all runners, and corrects them unless reportOnly is set. It returns the
+// drifted runners with both the stored and the recomputed values.
+func (rs RunnersService) RecomputeBests(ctx context.Context,
+	runnerID string, reportOnly bool) ([]*models.BestsDrift, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.RecomputeBests")
+	defer span.End()
+	if runnerID != "" {
+		runner, responseErr := rs.runnersRepository.GetRunner(ctx, runnerID)
+		if responseErr != nil {
+			return nil, responseErr
+		}
+		if runner.ID == "" {
 			return nil, &models.ResponseError{
-				Message: "Invalid year",
-				Status:  http.StatusBadRequest,
+				Message: "Runner not found",
+				Status:  http.StatusNotFound,
 			}
 		}
-		return rs.runnersRepository.GetRunnersByYear(intYear)
 	}
-	return rs.runnersRepository.GetAllRunners()
+	seasonStart, seasonEnd := rs.seasonCalendar.SeasonDates(time.Now())
+	drifted, responseErr := rs.runnersRepository.GetDriftedBests(ctx,
+		runnerID, seasonStart, seasonEnd)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if reportOnly || len(drifted) == 0 {
+		return drifted, nil
+	}
+	transaction, err := repositories.BeginTransaction(ctx,
+		rs.resultsRepository)
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: "Failed to start transaction",
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	for _, drift := range drifted {
+		responseErr = rs.runnersRepository.UpdateRunnerResults(ctx, transaction, &models.Runner{
+			ID:           drift.RunnerID,
+			PersonalBest: drift.PersonalBest,
+			SeasonBest:   drift.SeasonBest,
+		})
+		if responseErr != nil {
+			transaction.Rollback()
+			return nil, responseErr
+		}
+	}
+	err = transaction.Commit()
+	if err != nil {
+		return nil, &models.ResponseError{
+			Message: "Failed to commit transaction",
+			Status:  http.StatusInternalServerError,
+		}
+	}
+	return drifted, nil
+}
+
+// RolloverSeason resets the season best of every runner to their best result
+// in the current season. It runs when a new season starts, and running it
+// again within the same season changes nothing.
+func (rs RunnersService) RolloverSeason(ctx context.Context) (*models.SeasonRollover, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "RunnersService.RolloverSeason")
+	defer span.End()
+	seasonStart, seasonEnd := rs.seasonCalendar.SeasonDates(time.Now())
+	updated, responseErr := rs.runnersRepository.ResetSeasonBests(ctx,
+		seasonStart, seasonEnd)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return &models.SeasonRollover{
+		SeasonStart:    seasonStart,
+		SeasonEnd:      seasonEnd,
+		RunnersUpdated: updated,
+	}, nil
 }
 
 func validateRunner(runner *models.Runner) *models.ResponseError {
@@ -115,6 +333,16 @@ func validateRunner(runner *models.Runner) *models.ResponseError {
 			Status:  http.StatusBadRequest,
 		}
 	}
+	if runner.DateOfBirth != "" {
+		dateOfBirth, err := time.Parse(dateLayout, runner.DateOfBirth)
+		if err != nil {
+			return &models.ResponseError{
+				Message: "Invalid date of birth",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		runner.Age = models.AgeOn(dateOfBirth, time.Now())
+	}
 	if runner.Age <= 16 || runner.Age > 125 {
 		return &models.ResponseError{
 			Message: "Invalid age",
@@ -127,6 +355,13 @@ func validateRunner(runner *models.Runner) *models.ResponseError {
 			Status:  http.StatusBadRequest,
 		}
 	}
+	runner.Category = strings.ToUpper(runner.Category)
+	if runner.Category != "" && !models.ValidCategory(runner.Category) {
+		return &models.ResponseError{
+			Message: "Invalid category",
+			Status:  http.StatusBadRequest,
+		}
+	}
 	return nil
 }
 
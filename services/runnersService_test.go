@@ -0,0 +1,54 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestLeaderboardFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		category string
+		ageGroup string
+		want     repositories.LeaderboardFilter
+		wantErr  string
+	}{
+		{name: "No_Filter"},
+		{
+			name:     "Category",
+			category: "w",
+			want:     repositories.LeaderboardFilter{Category: "W"},
+		},
+		{
+			name:     "Age_Group",
+			ageGroup: "m40",
+			want:     repositories.LeaderboardFilter{Category: "M", MinAge: 40, MaxAge: 44},
+		},
+		{
+			name:     "Matching_Category_And_Age_Group",
+			category: "W",
+			ageGroup: "WSEN",
+			want:     repositories.LeaderboardFilter{Category: "W", MinAge: 20, MaxAge: 34},
+		},
+		{
+			name:     "Conflicting_Category",
+			category: "M",
+			ageGroup: "W35",
+			wantErr:  "Category does not match age group",
+		},
+		{name: "Invalid_Category", category: "Q", wantErr: "Invalid category"},
+		{name: "Invalid_Age_Group", ageGroup: "M42", wantErr: "Invalid age group"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, responseErr := leaderboardFilter(test.category, test.ageGroup)
+			if test.wantErr != "" {
+				assert.Equal(t, test.wantErr, responseErr.Message)
+				return
+			}
+			assert.Equal(t, true, responseErr == nil)
+			assert.Equal(t, test.want, filter)
+		})
+	}
+}
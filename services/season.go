@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayout is the format of race dates in the API and in SQL parameters.
+const dateLayout = "2006-01-02"
+
+// SeasonCalendar defines when a season starts. Road seasons usually follow
+// the calendar year, cross-country seasons often start in autumn. A season is
+// named after the year it starts in.
+type SeasonCalendar struct {
+	location   *time.Location
+	startMonth time.Month
+	startDay   int
+}
+
+// NewSeasonCalendar creates a calendar whose seasons start on start, given as
+// "MM-DD", at midnight in timezone.
+func NewSeasonCalendar(timezone, start string) (*SeasonCalendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid season timezone %q: %w", timezone, err)
+	}
+	startDate, err := time.Parse("01-02", start)
+	if err != nil || startDate.Month() == time.February && startDate.Day() == 29 {
+		return nil, fmt.Errorf("invalid season start %q, expected MM-DD", start)
+	}
+	return &SeasonCalendar{
+		location:   location,
+		startMonth: startDate.Month(),
+		startDay:   startDate.Day(),
+	}, nil
+}
+
+// Season returns the bounds of the season containing t. start is inclusive,
+// end is exclusive.
+func (sc SeasonCalendar) Season(t time.Time) (start, end time.Time) {
+	t = t.In(sc.location)
+	start = time.Date(t.Year(), sc.startMonth, sc.startDay, 0, 0, 0, 0, sc.location)
+	if t.Before(start) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	return start, start.AddDate(1, 0, 0)
+}
+
+// SeasonDates returns the bounds of the season containing t as "YYYY-MM-DD".
+func (sc SeasonCalendar) SeasonDates(t time.Time) (start, end string) {
+	startTime, endTime := sc.Season(t)
+	return startTime.Format(dateLayout), endTime.Format(dateLayout)
+}
+
+// NextStart returns the start of the season after the one containing t.
+func (sc SeasonCalendar) NextStart(t time.Time) time.Time {
+	_, end := sc.Season(t)
+	return end
+}
+
+// Contains reports whether a race held on raceDate, or in year when the
+// date is unknown, belongs to the season containing t. Undated races are
+// placed on July 1st of their year, which for calendar-year seasons is
+// equivalent to comparing years.
+func (sc SeasonCalendar) Contains(t time.Time, raceDate string, year int) bool {
+	start, end := sc.Season(t)
+	date := time.Date(year, time.July, 1, 0, 0, 0, 0, sc.location)
+	if raceDate != "" {
+		parsed, err := time.ParseInLocation(dateLayout, raceDate, sc.location)
+		if err != nil {
+			return false
+		}
+		date = parsed
+	}
+	return !date.Before(start) && date.Before(end)
+}
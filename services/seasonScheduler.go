@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// rolloverRetryInterval is how long the scheduler waits before retrying a
+// failed rollover.
+const rolloverRetryInterval = time.Minute
+
+// SeasonScheduler resets season bests when a new season starts.
+type SeasonScheduler struct {
+	runnersService *RunnersService
+	seasonCalendar *SeasonCalendar
+}
+
+func NewSeasonScheduler(runnersService *RunnersService,
+	seasonCalendar *SeasonCalendar) *SeasonScheduler {
+	return &SeasonScheduler{
+		runnersService: runnersService,
+		seasonCalendar: seasonCalendar,
+	}
+}
+
+// Run rolls the season over once at startup, catching up on a season start
+// missed while the server was down, and then at the start of every season
+// until ctx is cancelled.
+func (ss SeasonScheduler) Run(ctx context.Context) {
+	for {
+		wait := time.Until(ss.seasonCalendar.NextStart(time.Now()))
+		rollover, responseErr := ss.runnersService.RolloverSeason(ctx)
+		if responseErr != nil {
+			slog.ErrorContext(ctx, "Season rollover failed",
+				"error", responseErr.Message, "retry_in", rolloverRetryInterval)
+			wait = min(wait, rolloverRetryInterval)
+		} else {
+			slog.InfoContext(ctx, "Season rolled over",
+				"season_start", rollover.SeasonStart,
+				"season_end", rollover.SeasonEnd,
+				"runners_updated", rollover.RunnersUpdated,
+				"next_rollover_in", wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
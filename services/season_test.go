@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestSeason(t *testing.T) {
+	tests := []struct {
+		name      string
+		timezone  string
+		start     string
+		now       time.Time
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "Calendar_Year",
+			timezone:  "UTC",
+			start:     "01-01",
+			now:       time.Date(2024, time.June, 15, 12, 0, 0, 0, time.UTC),
+			wantStart: "2024-01-01",
+			wantEnd:   "2025-01-01",
+		},
+		{
+			name:      "Cross_Country_Before_Start",
+			timezone:  "UTC",
+			start:     "09-01",
+			now:       time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			wantStart: "2023-09-01",
+			wantEnd:   "2024-09-01",
+		},
+		{
+			name:      "Cross_Country_On_Start",
+			timezone:  "UTC",
+			start:     "09-01",
+			now:       time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC),
+			wantStart: "2024-09-01",
+			wantEnd:   "2025-09-01",
+		},
+		{
+			name:     "Timezone_Ahead_Of_UTC",
+			timezone: "Asia/Tokyo",
+			start:    "01-01",
+			// 2024-01-01 02:00 in Tokyo
+			now:       time.Date(2023, time.December, 31, 17, 0, 0, 0, time.UTC),
+			wantStart: "2024-01-01",
+			wantEnd:   "2025-01-01",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calendar, err := NewSeasonCalendar(test.timezone, test.start)
+			assert.Equal(t, nil, err)
+			start, end := calendar.Season(test.now)
+			assert.Equal(t, test.wantStart, start.Format(dateLayout))
+			assert.Equal(t, test.wantEnd, end.Format(dateLayout))
+			assert.Equal(t, end, calendar.NextStart(test.now))
+		})
+	}
+}
+
+func TestNewSeasonCalendarErrors(t *testing.T) {
+	_, err := NewSeasonCalendar("Mars/Olympus", "01-01")
+	assert.Equal(t, true, err != nil)
+	_, err = NewSeasonCalendar("UTC", "13-01")
+	assert.Equal(t, true, err != nil)
+	_, err = NewSeasonCalendar("UTC", "02-29")
+	assert.Equal(t, true, err != nil)
+}
+
+func TestSeasonContains(t *testing.T) {
+	calendar, _ := NewSeasonCalendar("UTC", "09-01")
+	now := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, true, calendar.Contains(now, "2024-09-01", 2024))
+	assert.Equal(t, true, calendar.Contains(now, "2025-03-10", 2025))
+	assert.Equal(t, false, calendar.Contains(now, "2024-08-31", 2024))
+	assert.Equal(t, false, calendar.Contains(now, "", 2024))
+	assert.Equal(t, true, calendar.Contains(now, "", 2025))
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"github.com/fentezi/runnerBook/models"
+	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type SplitsService struct {
+	splitsRepository  *repositories.SplitsRepository
+	resultsRepository *repositories.ResultsRepository
+	coachesService    *CoachesService
+}
+
+func NewSplitsService(splitsRepository *repositories.SplitsRepository,
+	resultsRepository *repositories.ResultsRepository,
+	coachesService *CoachesService) *SplitsService {
+	return &SplitsService{
+		splitsRepository:  splitsRepository,
+		resultsRepository: resultsRepository,
+		coachesService:    coachesService,
+	}
+}
+
+// AddSplits adds splits to a result on behalf of user, who must be able to
+// act for the runner. Together with the splits the result already has, split
+// times must increase with distance and must not exceed the race result.
+func (ss SplitsService) AddSplits(ctx context.Context, user *models.User,
+	resultID string, splits []*models.Split) (*models.SplitAnalysis, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "SplitsService.AddSplits")
+	defer span.End()
+	if resultID == "" {
+		return nil, &models.ResponseError{
+			Message: "Invalid result ID",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	if len(splits) == 0 {
+		return nil, &models.ResponseError{
+			Message: "No splits given",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	for _, split := range splits {
+		if split == nil {
+			return nil, &models.ResponseError{
+				Message: "Invalid split",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		if split.Distance <= 0 {
+			return nil, &models.ResponseError{
+				Message: "Invalid split distance",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		if split.ElapsedTime <= 0 {
+			return nil, &models.ResponseError{
+				Message: "Invalid split time",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	result, responseErr := ss.resultsRepository.GetResult(ctx, resultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if result == nil {
+		return nil, &models.ResponseError{
+			Message: "Result not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	responseErr = ss.coachesService.AuthorizeRunner(ctx, user, result.RunnerID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	existing, responseErr := ss.splitsRepository.GetSplits(ctx, resultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	for _, split := range splits {
+		if split.Distance > result.Distance {
+			return nil, &models.ResponseError{
+				Message: "Split distance exceeds race distance",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	responseErr = validateSplits(append(existing, splits...), result.RaceResult)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = ss.splitsRepository.CreateSplits(ctx, resultID, splits)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	all, responseErr := ss.splitsRepository.GetSplits(ctx, resultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return analyzeSplits(result, all), nil
+}
+
+// GetSplits returns the splits of a result with their segment paces and
+// pacing.
+func (ss SplitsService) GetSplits(ctx context.Context,
+	resultID string) (*models.SplitAnalysis, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "SplitsService.GetSplits")
+	defer span.End()
+	if resultID == "" {
+		return nil, &models.ResponseError{
+			Message: "Invalid result ID",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	result, responseErr := ss.resultsRepository.GetResult(ctx, resultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	if result == nil {
+		return nil, &models.ResponseError{
+			Message: "Result not found",
+			Status:  http.StatusNotFound,
+		}
+	}
+	splits, responseErr := ss.splitsRepository.GetSplits(ctx, resultID)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return analyzeSplits(result, splits), nil
+}
+
+func validateSplits(splits []*models.Split,
+	raceResult models.RaceTime) *models.ResponseError {
+	sorted := append([]*models.Split(nil), splits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Distance < sorted[j].Distance
+	})
+	for i, split := range sorted {
+		if raceResult > 0 && split.ElapsedTime > raceResult {
+			return &models.ResponseError{
+				Message: "Split time exceeds race result",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		if split.Distance == sorted[i-1].Distance {
+			return &models.ResponseError{
+				Message: "Duplicate split distance",
+				Status:  http.StatusBadRequest,
+			}
+		}
+		if split.ElapsedTime <= sorted[i-1].ElapsedTime {
+			return &models.ResponseError{
+				Message: "Split times must increase with distance",
+				Status:  http.StatusBadRequest,
+			}
+		}
+	}
+	return nil
+}
+
+// analyzeSplits fills in the segment time and pace of splits ordered by
+// distance, rounding paces to the precision of result, and compares the two
+// halves of the race. The time at halfway is interpolated between the splits
+// around it, the finish counting as the last split when result has a time.
+func analyzeSplits(result *models.Result,
+	splits []*models.Split) *models.SplitAnalysis {
+	unit := time.Second
+	for range result.Precision {
+		unit /= 10
+	}
+	previousDistance, previousTime := 0, models.RaceTime(0)
+	for _, split := range splits {
+		split.SegmentTime = split.ElapsedTime - previousTime
+		segment := float64(split.Distance - previousDistance)
+		pace := time.Duration(float64(split.SegmentTime) * 1000 / segment)
+		split.Pace = models.RaceTime(pace.Round(unit))
+		previousDistance, previousTime = split.Distance, split.ElapsedTime
+	}
+	analysis := &models.SplitAnalysis{
+		ResultID: result.ID,
+		Splits:   splits,
+	}
+	points := splits
+	if len(splits) > 0 && result.RaceResult > 0 &&
+		result.Distance > splits[len(splits)-1].Distance {
+		points = append(points[:len(points):len(points)], &models.Split{
+			Distance:    result.Distance,
+			ElapsedTime: result.RaceResult,
+		})
+	}
+	if len(points) < 2 {
+		return analysis
+	}
+	last := points[len(points)-1]
+	halfway := float64(last.Distance) / 2
+	firstHalf := interpolateTime(points, halfway)
+	secondHalf := float64(last.ElapsedTime) - firstHalf
+	switch {
+	case secondHalf < firstHalf:
+		analysis.Pacing = models.PacingNegative
+	case secondHalf > firstHalf:
+		analysis.Pacing = models.PacingPositive
+	default:
+		analysis.Pacing = models.PacingEven
+	}
+	return analysis
+}
+
+// interpolateTime returns the elapsed time at distance, assuming an even
+// pace between splits.
+func interpolateTime(splits []*models.Split, distance float64) float64 {
+	previousDistance, previousTime := 0.0, 0.0
+	for _, split := range splits {
+		splitDistance := float64(split.Distance)
+		if distance <= splitDistance {
+			fraction := (distance - previousDistance) / (splitDistance - previousDistance)
+			return previousTime + fraction*(float64(split.ElapsedTime)-previousTime)
+		}
+		previousDistance, previousTime = splitDistance, float64(split.ElapsedTime)
+	}
+	return previousTime
+}
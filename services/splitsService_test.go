@@ -0,0 +1,112 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func split(distance int, elapsed time.Duration) *models.Split {
+	return &models.Split{Distance: distance, ElapsedTime: models.RaceTime(elapsed)}
+}
+
+func TestAnalyzeSplits(t *testing.T) {
+	tests := []struct {
+		name       string
+		splits     []*models.Split
+		result     models.Result
+		wantPaces  []time.Duration
+		wantPacing string
+	}{
+		{
+			name:       "Negative_Split",
+			splits:     []*models.Split{split(5000, 20*time.Minute), split(10000, 39*time.Minute)},
+			wantPaces:  []time.Duration{4 * time.Minute, 3*time.Minute + 48*time.Second},
+			wantPacing: models.PacingNegative,
+		},
+		{
+			name:       "Positive_Split",
+			splits:     []*models.Split{split(5000, 19*time.Minute), split(10000, 39*time.Minute)},
+			wantPaces:  []time.Duration{3*time.Minute + 48*time.Second, 4 * time.Minute},
+			wantPacing: models.PacingPositive,
+		},
+		{
+			name: "Halfway_Interpolated",
+			splits: []*models.Split{split(4000, 16*time.Minute),
+				split(8000, 32*time.Minute), split(10000, 40*time.Minute)},
+			wantPaces:  []time.Duration{4 * time.Minute, 4 * time.Minute, 4 * time.Minute},
+			wantPacing: models.PacingEven,
+		},
+		{
+			name:      "Pace_Rounded_To_Precision",
+			splits:    []*models.Split{split(300, 40*time.Second)},
+			result:    models.Result{Precision: 2},
+			wantPaces: []time.Duration{2*time.Minute + 13*time.Second + 330*time.Millisecond},
+		},
+		{
+			name: "Finish_Counts_As_Last_Split",
+			splits: []*models.Split{split(10000, 30*time.Minute),
+				split(20000, time.Hour), split(30000, 90*time.Minute),
+				split(40000, 2*time.Hour)},
+			result: models.Result{Distance: models.MarathonDistance,
+				RaceResult: models.RaceTime(2*time.Hour + 10*time.Minute)},
+			wantPaces: []time.Duration{3 * time.Minute, 3 * time.Minute,
+				3 * time.Minute, 3 * time.Minute},
+			wantPacing: models.PacingPositive,
+		},
+		{
+			name:      "Single_Split",
+			splits:    []*models.Split{split(1000, 3*time.Minute)},
+			wantPaces: []time.Duration{3 * time.Minute},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			analysis := analyzeSplits(&test.result, test.splits)
+			assert.Equal(t, test.wantPacing, analysis.Pacing)
+			for i, split := range analysis.Splits {
+				assert.Equal(t, test.wantPaces[i], split.Pace.Duration())
+			}
+		})
+	}
+}
+
+func TestValidateSplits(t *testing.T) {
+	raceResult := models.RaceTime(40 * time.Minute)
+	tests := []struct {
+		name    string
+		splits  []*models.Split
+		wantErr string
+	}{
+		{
+			name:   "Valid_Out_Of_Order",
+			splits: []*models.Split{split(10000, 40*time.Minute), split(5000, 20*time.Minute)},
+		},
+		{
+			name:    "Duplicate_Distance",
+			splits:  []*models.Split{split(5000, 20*time.Minute), split(5000, 21*time.Minute)},
+			wantErr: "Duplicate split distance",
+		},
+		{
+			name:    "Time_Decreasing",
+			splits:  []*models.Split{split(5000, 20*time.Minute), split(6000, 19*time.Minute)},
+			wantErr: "Split times must increase with distance",
+		},
+		{
+			name:    "Exceeds_Race_Result",
+			splits:  []*models.Split{split(10000, 41*time.Minute)},
+			wantErr: "Split time exceeds race result",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			responseErr := validateSplits(test.splits, raceResult)
+			if test.wantErr == "" {
+				assert.Equal(t, true, responseErr == nil)
+				return
+			}
+			assert.Equal(t, test.wantErr, responseErr.Message)
+		})
+	}
+}
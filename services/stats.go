@@ -0,0 +1,109 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"math"
+	"sort"
+)
+
+// runnerStats computes the statistics of a runner from their results.
+// Distances are ordered by length and the yearly bests and progression of a
+// distance by date. Undated results count on July 1st of their year.
+func runnerStats(runnerID string, results []*models.Result) *models.RunnerStats {
+	stats := &models.RunnerStats{
+		RunnerID:     runnerID,
+		Races:        len(results),
+		StatusCounts: map[string]int{},
+		Years:        []*models.YearStats{},
+		Distances:    []*models.DistanceStats{},
+	}
+	sorted := append([]*models.Result(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return resultDate(sorted[i]).Before(resultDate(sorted[j]))
+	})
+	years := map[int]*models.YearStats{}
+	distances := map[int]*models.DistanceStats{}
+	positions, placed := 0, 0
+	for _, result := range sorted {
+		stats.StatusCounts[result.Status]++
+		year := years[result.Year]
+		if year == nil {
+			year = &models.YearStats{Year: result.Year}
+			years[result.Year] = year
+			stats.Years = append(stats.Years, year)
+		}
+		year.Races++
+		if result.Status != models.StatusFinished {
+			continue
+		}
+		year.Finished++
+		if result.Position > 0 {
+			positions += result.Position
+			placed++
+		}
+		if result.RaceResult <= 0 {
+			continue
+		}
+		distance := distances[result.Distance]
+		if distance == nil {
+			distance = &models.DistanceStats{
+				Distance:    result.Distance,
+				YearlyBests: []*models.YearlyBest{},
+				Progression: []*models.ProgressionPoint{},
+			}
+			distances[result.Distance] = distance
+			stats.Distances = append(stats.Distances, distance)
+		}
+		addToDistance(distance, result)
+	}
+	if placed > 0 {
+		stats.AveragePosition = math.Round(float64(positions)/float64(placed)*100) / 100
+	}
+	sort.Slice(stats.Years, func(i, j int) bool {
+		return stats.Years[i].Year < stats.Years[j].Year
+	})
+	sort.Slice(stats.Distances, func(i, j int) bool {
+		return stats.Distances[i].Distance < stats.Distances[j].Distance
+	})
+	for _, distance := range stats.Distances {
+		sort.Slice(distance.YearlyBests, func(i, j int) bool {
+			return distance.YearlyBests[i].Year < distance.YearlyBests[j].Year
+		})
+		for i := 1; i < len(distance.YearlyBests); i++ {
+			previous := distance.YearlyBests[i-1].Time
+			current := distance.YearlyBests[i].Time
+			improvement := math.Round(float64(previous-current)/float64(previous)*10000) / 100
+			distance.YearlyBests[i].Improvement = &improvement
+		}
+	}
+	return stats
+}
+
+// addToDistance adds a finished result, taken in the order of race dates, to
+// the statistics of its distance.
+func addToDistance(distance *models.DistanceStats, result *models.Result) {
+	distance.Races++
+	if distance.Best == 0 || result.RaceResult < distance.Best {
+		distance.Best = result.RaceResult
+	}
+	distance.Progression = append(distance.Progression, &models.ProgressionPoint{
+		Date:     resultDate(result).Format(dateLayout),
+		ResultID: result.ID,
+		Time:     result.RaceResult,
+		Best:     distance.Best,
+	})
+	for _, yearlyBest := range distance.YearlyBests {
+		if yearlyBest.Year == result.Year {
+			if result.RaceResult < yearlyBest.Time {
+				yearlyBest.Time = result.RaceResult
+				yearlyBest.ResultID = result.ID
+			}
+			return
+		}
+	}
+	distance.YearlyBests = append(distance.YearlyBests, &models.YearlyBest{
+		Year:     result.Year,
+		Time:     result.RaceResult,
+		ResultID: result.ID,
+	})
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestRunnerStats(t *testing.T) {
+	result := func(id string, year int, date string, distance int,
+		minutes int, position int, status string) *models.Result {
+		return &models.Result{
+			ID:         id,
+			Year:       year,
+			RaceDate:   date,
+			Distance:   distance,
+			RaceResult: models.RaceTime(time.Duration(minutes) * time.Minute),
+			Position:   position,
+			Status:     status,
+		}
+	}
+	results := []*models.Result{
+		result("m3", 2023, "2023-10-01", 42195, 190, 12, models.StatusFinished),
+		result("m1", 2022, "2022-04-10", 42195, 200, 30, models.StatusFinished),
+		result("h1", 2022, "", 21098, 95, 0, models.StatusFinished),
+		result("m2", 2022, "2022-10-09", 42195, 210, 6, models.StatusFinished),
+		result("m4", 2024, "2024-04-21", 42195, 0, 0, models.StatusDidNotFinish),
+		result("m5", 2024, "2024-10-06", 42195, 195, 0, models.StatusFinished),
+	}
+	stats := runnerStats("1", results)
+	assert.Equal(t, stats.Races, 6)
+	assert.Equal(t, stats.StatusCounts, map[string]int{
+		models.StatusFinished:     5,
+		models.StatusDidNotFinish: 1,
+	})
+	assert.Equal(t, stats.AveragePosition, 16.0)
+	assert.Equal(t, stats.Years, []*models.YearStats{
+		{Year: 2022, Races: 3, Finished: 3},
+		{Year: 2023, Races: 1, Finished: 1},
+		{Year: 2024, Races: 2, Finished: 1},
+	})
+	assert.Equal(t, len(stats.Distances), 2)
+
+	half := stats.Distances[0]
+	assert.Equal(t, half.Distance, 21098)
+	assert.Equal(t, half.Races, 1)
+	assert.Equal(t, half.Progression[0].Date, "2022-07-01")
+	assert.Equal(t, half.YearlyBests[0].Improvement == nil, true)
+
+	marathon := stats.Distances[1]
+	assert.Equal(t, marathon.Races, 4)
+	assert.Equal(t, marathon.Best, models.RaceTime(190*time.Minute))
+	bests := []string{}
+	for _, point := range marathon.Progression {
+		bests = append(bests, point.ResultID+" "+point.Best.String())
+	}
+	assert.Equal(t, bests, []string{
+		"m1 03:20:00", "m2 03:20:00", "m3 03:10:00", "m5 03:10:00",
+	})
+	years := []int{}
+	improvements := []float64{}
+	for _, yearlyBest := range marathon.YearlyBests {
+		years = append(years, yearlyBest.Year)
+		if yearlyBest.Improvement != nil {
+			improvements = append(improvements, *yearlyBest.Improvement)
+		}
+	}
+	assert.Equal(t, years, []int{2022, 2023, 2024})
+	assert.Equal(t, marathon.YearlyBests[0].ResultID, "m1")
+	// 3:20:00 to 3:10:00 is 5% faster, 3:10:00 to 3:15:00 2.63% slower.
+	assert.Equal(t, improvements, []float64{5, -2.63})
+}
+
+func TestRunnerStatsNoResults(t *testing.T) {
+	stats := runnerStats("1", []*models.Result{})
+	assert.Equal(t, stats.Races, 0)
+	assert.Equal(t, stats.AveragePosition, 0.0)
+	assert.Equal(t, len(stats.Years), 0)
+	assert.Equal(t, len(stats.Distances), 0)
+}
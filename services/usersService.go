@@ -1,34 +1,51 @@
 package services
 
 import (
+	"context"
 	"encoding/base64"
+	"fmt"
+	"github.com/fentezi/runnerBook/metrics"
 	"github.com/fentezi/runnerBook/models"
 	"github.com/fentezi/runnerBook/repositories"
+	"github.com/fentezi/runnerBook/tracing"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
+	"regexp"
 )
 
+const minPasswordLength = 8
+
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
 type UsersService struct {
 	usersRepository *repositories.UsersRepository
+	rolesRepository *repositories.RolesRepository
 }
 
-func NewUsersService(usersRepository *repositories.UsersRepository) *UsersService {
-	return &UsersService{usersRepository: usersRepository}
+func NewUsersService(usersRepository *repositories.UsersRepository,
+	rolesRepository *repositories.RolesRepository) *UsersService {
+	return &UsersService{
+		usersRepository: usersRepository,
+		rolesRepository: rolesRepository,
+	}
 }
 
-func (uc UsersService) Login(username, password string) (string, *models.ResponseError) {
+func (uc UsersService) Login(ctx context.Context, username, password string) (string, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "UsersService.Login")
+	defer span.End()
 	if username == "" || password == "" {
 		return "", &models.ResponseError{
 			Message: "Invalid username or password",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	id, responseErr := uc.usersRepository.LoginUser(
+	id, responseErr := uc.usersRepository.LoginUser(ctx,
 		username, password)
 	if responseErr != nil {
 		return "", responseErr
 	}
 	if id == "" {
+		metrics.LoginFailures.Inc()
 		return "", &models.ResponseError{
 			Message: "Login failed",
 			Status:  http.StatusUnauthorized,
@@ -38,43 +55,131 @@ func (uc UsersService) Login(username, password string) (string, *models.Respons
 	if responseErr != nil {
 		return "", responseErr
 	}
-	uc.usersRepository.SetAccessToken(accessToken, id)
+	uc.usersRepository.SetAccessToken(ctx, accessToken, id)
 	return accessToken, nil
 }
 
-func (uc UsersService) Logout(accessToken string) *models.ResponseError {
+func (uc UsersService) Logout(ctx context.Context, accessToken string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "UsersService.Logout")
+	defer span.End()
 	if accessToken == "" {
 		return &models.ResponseError{
 			Message: "Invalid access token",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	return uc.usersRepository.RemoveAccessToken(accessToken)
+	return uc.usersRepository.RemoveAccessToken(ctx, accessToken)
 }
 
-func (uc UsersService) AuthorizeUser(accessToken string, expectedRoles []string) (bool, *models.ResponseError) {
+// AuthorizedUser returns the user signed in with accessToken when their role
+// grants permission, and nil when it does not. Services scoping what a user
+// may do, such as a coach acting for their athletes, take it.
+func (uc UsersService) AuthorizedUser(ctx context.Context, accessToken,
+	permission string) (*models.User, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "UsersService.AuthorizedUser")
+	defer span.End()
 	if accessToken == "" {
-		return false, &models.ResponseError{
+		return nil, &models.ResponseError{
 			Message: "Invalid access token",
 			Status:  http.StatusBadRequest,
 		}
 	}
-	role, responseErr := uc.usersRepository.GetUserRole(accessToken)
+	user, responseErr := uc.usersRepository.GetUserByToken(ctx, accessToken)
 	if responseErr != nil {
-		return false, responseErr
+		return nil, responseErr
 	}
-	if role == "" {
-		return false, &models.ResponseError{
+	if user == nil {
+		return nil, &models.ResponseError{
 			Message: "Failed to authorize user",
 			Status:  http.StatusUnauthorized,
 		}
 	}
-	for _, expectedRole := range expectedRoles {
-		if expectedRole == role {
-			return true, nil
+	if !user.HasPermission(permission) {
+		return nil, nil
+	}
+	return user, nil
+}
+
+func (uc UsersService) CreateUser(ctx context.Context, user *models.User) (*models.User, *models.ResponseError) {
+	ctx, span := tracing.StartSpan(ctx, "UsersService.CreateUser")
+	defer span.End()
+	if user.Username == "" {
+		return nil, &models.ResponseError{
+			Message: "Invalid username",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	responseErr := validatePassword(user.Password)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	responseErr = uc.checkRole(ctx, user.Role)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return uc.usersRepository.CreateUser(ctx, user)
+}
+
+func (uc UsersService) SetPassword(ctx context.Context, username, password string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "UsersService.SetPassword")
+	defer span.End()
+	responseErr := validatePassword(password)
+	if responseErr != nil {
+		return responseErr
+	}
+	return uc.usersRepository.SetPassword(ctx, username, password)
+}
+
+func (uc UsersService) SetRole(ctx context.Context, username, role string) *models.ResponseError {
+	ctx, span := tracing.StartSpan(ctx, "UsersService.SetRole")
+	defer span.End()
+	responseErr := uc.checkRole(ctx, role)
+	if responseErr != nil {
+		return responseErr
+	}
+	return uc.usersRepository.SetRole(ctx, username, role)
+}
+
+// checkRole checks that the role named role exists.
+func (uc UsersService) checkRole(ctx context.Context, role string) *models.ResponseError {
+	responseErr := validateRole(role)
+	if responseErr != nil {
+		return responseErr
+	}
+	existing, responseErr := uc.rolesRepository.GetRole(ctx, role)
+	if responseErr != nil {
+		return responseErr
+	}
+	if existing == nil {
+		return &models.ResponseError{
+			Message: "Invalid role",
+			Status:  http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+func validatePassword(password string) *models.ResponseError {
+	if len(password) < minPasswordLength {
+		return &models.ResponseError{
+			Message: fmt.Sprintf("Password must be at least %d characters",
+				minPasswordLength),
+			Status: http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+// validateRole checks the name of a role: lower case letters, digits, dashes
+// and underscores, starting with a letter.
+func validateRole(role string) *models.ResponseError {
+	if !roleName.MatchString(role) {
+		return &models.ResponseError{
+			Message: "Invalid role",
+			Status:  http.StatusBadRequest,
 		}
 	}
-	return false, nil
+	return nil
 }
 
 func generateAccessToken(username string) (string, *models.ResponseError) {
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/fentezi/runnerBook/models"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestValidateRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{models.RoleAdmin, true},
+		{models.RoleRunner, true},
+		{models.RoleCoach, true},
+		{"", false},
+		{"Coach", false},
+	}
+	for _, test := range tests {
+		t.Run(test.role, func(t *testing.T) {
+			assert.Equal(t, validateRole(test.role) == nil, test.want)
+		})
+	}
+}
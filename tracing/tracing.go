@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ServiceName         = "runnerbook"
+	instrumentationName = "github.com/fentezi/runnerBook"
+)
+
+// InitTracing installs the global tracer provider selected by
+// tracing.exporter ("none", "stdout" or "otlp") and the W3C trace-context
+// propagator. With "none", the default, spans are not recorded or exported,
+// but incoming trace context is still propagated. The returned function
+// flushes and stops the exporter.
+func InitTracing(config *viper.Viper) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.GetString("tracing.exporter") {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		options := []otlptracehttp.Option{}
+		if endpoint := config.GetString("tracing.otlp_endpoint"); endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if config.GetBool("tracing.otlp_insecure") {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q",
+			config.GetString("tracing.exporter"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	sampleRatio := 1.0
+	if config.IsSet("tracing.sample_ratio") {
+		sampleRatio = config.GetFloat64("tracing.sample_ratio")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span named after the service method it instruments,
+// e.g. "RunnersService.GetRunner".
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name)
+}